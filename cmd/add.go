@@ -15,4 +15,5 @@ var addCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.PersistentFlags().StringP("session", "s", "keycloak", "Name of the session to use")
+	addRealmFlag(addCmd)
 }
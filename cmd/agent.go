@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/infrastructure/agent"
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run an agent, that keeps sessions in memory",
+	Long: `Run an agent, that keeps sessions in memory.
+
+The agent serves sessions over a unix socket and refreshes them before they
+expire. Other keycli commands use the agent, if the environment variable
+KEYCLI_AGENT_SOCK points to its socket. Otherwise they access the session files
+directly. Only processes of the same user are allowed to connect to the agent.
+The agent is available on Linux only.`,
+	Example: `  # Start the agent in the background and use it in the current shell
+  keycli agent --socket ~/.keycli-agent.sock &
+  export KEYCLI_AGENT_SOCK=~/.keycli-agent.sock`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		socketPath, _ := cmd.Flags().GetString("socket")
+		socketPath = strings.TrimSpace(socketPath)
+
+		//
+		// run agent
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return cli.Agent(ctx, container, socketPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.Flags().String("socket", agent.DefaultSocketPath(), "Path of the agent socket")
+}
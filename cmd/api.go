@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api PATH",
+	Short: "Make an authenticated request to the Keycloak admin REST API",
+	Long: `Make an authenticated request to the Keycloak admin REST API.
+
+The PATH is relative to the root of the admin REST API (e.g.: realms/master/users).
+The placeholder {realm} is replaced with the realm given by --realm or else with
+the target realm of the session, which defaults to the login realm. The access
+token is refreshed automatically and JSON responses are pretty-printed.
+
+Fields passed via --field are sent as query parameters for GET and DELETE
+requests and as JSON object for any other request. The default method is GET or
+POST, if fields or an input is given.`,
+	Example: `  # List the users of the session realm
+  api 'realms/{realm}/users'
+
+  # List all users by following the pagination
+  api --paginate 'realms/{realm}/users'
+
+  # Create a group
+  api -X POST 'realms/{realm}/groups' -f name=developers
+
+  # Create a client from a JSON file
+  api 'realms/{realm}/clients' --input client.json`,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name, _ := cmd.Flags().GetString("session")
+		name = strings.TrimSpace(name)
+		realm := realmFlag(cmd)
+
+		path := strings.TrimSpace(args[0])
+		fields, _ := cmd.Flags().GetStringArray("field")
+		input, _ := cmd.Flags().GetString("input")
+		paginate, _ := cmd.Flags().GetBool("paginate")
+
+		method, _ := cmd.Flags().GetString("method")
+		if !cmd.Flags().Changed("method") && (len(fields) > 0 || input != "") {
+			method = http.MethodPost
+		}
+
+		//
+		// send request
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.API(ctx, container, name, realm, method, path, fields, input, paginate))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.Flags().StringP("session", "s", "keycloak", "Name of the session to use")
+	apiCmd.Flags().String("realm", "", "Realm to replace the {realm} placeholder with (defaults to the target realm of the session)")
+	apiCmd.Flags().StringP("method", "X", http.MethodGet, "HTTP method of the request")
+	apiCmd.Flags().StringArrayP("field", "f", []string{}, "Request parameter, can be specified multiple times (e.g.: key=value)")
+	apiCmd.Flags().String("input", "", "File to use as request body (use '-' to read from stdin)")
+	apiCmd.Flags().Bool("paginate", false, "Fetch all pages of a list endpoint")
+}
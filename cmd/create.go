@@ -13,4 +13,5 @@ var createCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(createCmd)
 	createCmd.PersistentFlags().StringP("session", "s", "keycloak", "Name of the session to use")
+	addRealmFlag(createCmd)
 }
@@ -13,4 +13,5 @@ var deleteCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.PersistentFlags().StringP("session", "s", "keycloak", "Name of the session to use")
+	addRealmFlag(deleteCmd)
 }
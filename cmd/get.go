@@ -13,4 +13,5 @@ var getCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(getCmd)
 	getCmd.PersistentFlags().StringP("session", "s", "keycloak", "Name of the session to use")
+	addRealmFlag(getCmd)
 }
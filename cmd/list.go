@@ -13,4 +13,5 @@ var listCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.PersistentFlags().StringP("session", "s", "keycloak", "Name of the session to use")
+	addRealmFlag(listCmd)
 }
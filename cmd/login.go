@@ -1,15 +1,11 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
 	"strings"
 
-	"github.com/pkg/errors"
-
+	"github.com/aisbergg/keycli/pkg/core"
 	"github.com/aisbergg/keycli/pkg/interface/cli"
 	"github.com/spf13/cobra"
-	"golang.org/x/crypto/ssh/terminal"
 )
 
 // loginCmd represents the login command
@@ -21,7 +17,20 @@ var loginCmd = &cobra.Command{
 The session is tied to a specific server and realm. Multiple sessions for
 different servers and realms can be opened and named using the SESSION argument.
 Other commands accept the session name as an option, which will effectively
-execute the commands in the context of the given session.`,
+execute the commands in the context of the given session.
+
+Sessions are stored in the directory given by KEYCLI_HOME, in a project-local
+.keycli directory found in the current working directory or one of its parents,
+or in the user's state directory (e.g.: ~/.local/state/keycli), whichever comes
+first. A project-local directory allows each project to have its own sessions.
+
+Without --proxy or --no-proxy, the proxy is taken from the environment variables
+HTTPS_PROXY, HTTP_PROXY and NO_PROXY. The proxy settings are stored in the
+session and apply to all commands using it.
+
+The base path of the server ('/auth' for legacy WildFly based servers or none
+for Quarkus based ones) and its version are detected on login. Use the 'server'
+command to show them.`,
 	Example: `  # Ask for url, user and password and then login
   login
 
@@ -29,7 +38,22 @@ execute the commands in the context of the given session.`,
   login -l 'https://sso.example.org' -r foo -u bar -p secret
 
   # Create a session and name it 'baz'
-  login baz`,
+  login baz
+
+  # Login to the master realm and manage the realm customer-a by default
+  login -r master --target-realm customer-a
+
+  # Connect to Keycloak through an HTTP proxy
+  login --proxy http://proxy.example.org:3128
+
+  # Create a long-lived session for automation using an offline token
+  login --offline automation
+
+  # Read the password from a password manager instead of passing it as a flag
+  login -u admin --password-command 'pass show kc/admin'
+
+  # Read the password from stdin (e.g.: in a CI pipeline)
+  echo "$KC_PASSWORD" | login -u admin --password-stdin`,
 	Args:          cobra.MaximumNArgs(1),
 	SilenceErrors: true,
 	SilenceUsage:  true,
@@ -45,8 +69,10 @@ execute the commands in the context of the given session.`,
 
 		url, _ := cmd.Flags().GetString("url")
 		if url == "" {
-			fmt.Printf("Keycloak URL: ")
-			fmt.Scanln(&url)
+			var err error
+			if url, err = container.Prompt("Keycloak URL"); err != nil {
+				return err
+			}
 		}
 		url = strings.TrimSpace(url)
 		if !strings.HasSuffix(url, "/") {
@@ -56,25 +82,53 @@ execute the commands in the context of the given session.`,
 		realm, _ := cmd.Flags().GetString("realm")
 		realm = strings.TrimSpace(realm)
 		if realm == "" {
-			return errors.New("realm must not be empty")
+			return core.NewError(core.KindValidation, "realm must not be empty")
+		}
+
+		proxy, _ := cmd.Flags().GetString("proxy")
+		proxy = strings.TrimSpace(proxy)
+		noProxy, _ := cmd.Flags().GetBool("no-proxy")
+		if proxy != "" && noProxy {
+			return core.NewError(core.KindValidation, "only one of --proxy and --no-proxy can be given")
+		}
+		if proxy != "" {
+			if err := cli.ValidateProxyURL(proxy); err != nil {
+				return err
+			}
 		}
 
 		secretKey, _ := cmd.Flags().GetString("secret-key")
 		secretKey = strings.TrimSpace(secretKey)
+		if secretKeyFile, _ := cmd.Flags().GetString("secret-key-file"); secretKeyFile != "" {
+			if secretKey != "" {
+				return core.NewError(core.KindValidation, "only one of --secret-key and --secret-key-file can be given")
+			}
+			var err error
+			if secretKey, err = cli.ReadSecretFromFile(secretKeyFile); err != nil {
+				return err
+			}
+		}
+		cli.RegisterSecret(secretKey)
 
 		user, _ := cmd.Flags().GetString("user")
 		user = strings.TrimSpace(user)
-		password, _ := cmd.Flags().GetString("password")
+		password, err := readPassword(cmd)
+		if err != nil {
+			return err
+		}
 		if secretKey == "" {
 			if user == "" {
-				fmt.Printf("Keycloak Admin User: ")
-				fmt.Scanln(&user)
+				if passwordStdin, _ := cmd.Flags().GetBool("password-stdin"); passwordStdin {
+					return core.NewError(core.KindValidation, "--user must be given when using --password-stdin")
+				}
+				if user, err = container.Prompt("Keycloak Admin User"); err != nil {
+					return err
+				}
 			}
 			if password == "" {
-				fmt.Printf("Keycloak Admin Password: ")
-				p, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
-				fmt.Println()
-				password = string(p)
+				if password, err = container.PromptSecret("Keycloak Admin Password"); err != nil {
+					return err
+				}
 			}
 		}
 
@@ -83,20 +137,76 @@ execute the commands in the context of the given session.`,
 		clientID, _ := cmd.Flags().GetString("client-id")
 		clientID = strings.TrimSpace(clientID)
 
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		targetRealm, _ := cmd.Flags().GetString("target-realm")
+		targetRealm = strings.TrimSpace(targetRealm)
+
 		//
 		// perform login
 		//
-		return cli.Login(name, url, realm, clientID, secretKey, user, password, skipVerify)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		opts := core.SessionOptions{
+			Name:        name,
+			URL:         url,
+			Realm:       realm,
+			ClientID:    clientID,
+			SkipVerify:  skipVerify,
+			Offline:     offline,
+			TargetRealm: targetRealm,
+			Proxy:       proxy,
+			NoProxy:     noProxy,
+		}
+		return printResult(cli.Login(ctx, container, opts, secretKey, user, password))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(loginCmd)
-	loginCmd.Flags().StringP("url", "l", "", "Base URL of Keycloak server (e.g.: https://sso.example.org/)")
+	loginCmd.Flags().StringP("url", "l", "", "Base URL of Keycloak server without the '/auth' path (e.g.: https://sso.example.org/)")
 	loginCmd.Flags().StringP("realm", "r", "master", "Keycloak realm")
 	loginCmd.Flags().StringP("user", "u", "", "Keycloak admin user")
-	loginCmd.Flags().StringP("password", "p", "", "Keycloak admin user password")
+	loginCmd.Flags().StringP("password", "p", "", "Keycloak admin user password (visible in the process list, prefer the other password options)")
+	loginCmd.Flags().Bool("password-stdin", false, "Read the password from stdin")
+	loginCmd.Flags().String("password-file", "", "Read the password from the first line of a file")
+	loginCmd.Flags().String("password-command", "", "Read the password from the first line of the output of a command")
 	loginCmd.Flags().StringP("secret-key", "s", "", "Keycloak admin secret key")
+	loginCmd.Flags().String("secret-key-file", "", "Read the secret key from the first line of a file")
 	loginCmd.Flags().Bool("skip-verify", false, "Skip TLS certificate verification")
 	loginCmd.Flags().String("client-id", "admin-cli", "Client ID to be used")
+	loginCmd.Flags().String("target-realm", "", "Realm managed by default, if it differs from the login realm")
+	loginCmd.Flags().String("proxy", "", "URL of the HTTP proxy used for connecting to Keycloak (e.g.: http://proxy.example.org:3128)")
+	loginCmd.Flags().Bool("no-proxy", false, "Connect to Keycloak directly, ignoring the proxy environment variables")
+	loginCmd.Flags().Bool("offline", false, "Request an offline token, which outlives the SSO session idle timeout")
+}
+
+// readPassword reads the password from the source given by the flags. An empty
+// password is returned, if no source is given.
+func readPassword(cmd *cobra.Command) (string, error) {
+	password, _ := cmd.Flags().GetString("password")
+	passwordStdin, _ := cmd.Flags().GetBool("password-stdin")
+	passwordFile, _ := cmd.Flags().GetString("password-file")
+	passwordCommand, _ := cmd.Flags().GetString("password-command")
+
+	sources := 0
+	for _, given := range []bool{password != "", passwordStdin, passwordFile != "", passwordCommand != ""} {
+		if given {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", core.NewError(core.KindValidation, "only one of --password, --password-stdin, --password-file and --password-command can be given")
+	}
+
+	switch {
+	case passwordStdin:
+		return container.ReadSecretFromStdin()
+	case passwordFile != "":
+		return cli.ReadSecretFromFile(passwordFile)
+	case passwordCommand != "":
+		return container.ReadSecretFromCommand(passwordCommand)
+	}
+	cli.RegisterSecret(password)
+	return password, nil
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"strings"
 
+	"github.com/aisbergg/keycli/pkg/core"
 	"github.com/aisbergg/keycli/pkg/interface/cli"
 	"github.com/spf13/cobra"
 )
@@ -17,7 +18,10 @@ var logoutCmd = &cobra.Command{
   logout baz
 
   # Delete session keys, even if the session couldn't be successfully ended
-  logout -f`,
+  logout -f
+
+  # End all stored sessions and revoke their tokens
+  logout --all --revoke`,
 	Args:          cobra.MaximumNArgs(1),
 	SilenceErrors: true,
 	SilenceUsage:  true,
@@ -25,6 +29,10 @@ var logoutCmd = &cobra.Command{
 		//
 		// parse flags and args
 		//
+		all, _ := cmd.Flags().GetBool("all")
+		if all && len(args) > 0 {
+			return core.NewError(core.KindValidation, "a session name cannot be given together with --all")
+		}
 		name := "keycloak"
 		if len(args) > 0 {
 			name = args[0]
@@ -32,15 +40,30 @@ var logoutCmd = &cobra.Command{
 		name = strings.TrimSpace(name)
 
 		force, _ := cmd.Flags().GetBool("force")
+		revoke, _ := cmd.Flags().GetBool("revoke")
 
 		//
 		// perform logout
 		//
-		return cli.Logout(name, force)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		if all {
+			// the summary is printed, even if some sessions weren't ended
+			result, err := cli.LogoutAll(ctx, container, force, revoke)
+			if result != nil {
+				if printErr := result.Print(container.Printer); err == nil {
+					err = printErr
+				}
+			}
+			return err
+		}
+		return printResult(cli.Logout(ctx, container, name, force, revoke))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(logoutCmd)
-	logoutCmd.Flags().BoolP("force", "f", false, "Force deletion of tokens, even if the session couldn't be terminated")
+	logoutCmd.Flags().BoolP("force", "f", false, "Force deletion of tokens, even if the session couldn't be terminated or loaded")
+	logoutCmd.Flags().Bool("all", false, "End all stored sessions")
+	logoutCmd.Flags().Bool("revoke", false, "Revoke the access and refresh tokens using the token revocation endpoint (RFC 7009)")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local proxy, that authorizes requests to Keycloak",
+	Long: `Run a local proxy, that authorizes requests to Keycloak.
+
+Requests sent to the proxy are forwarded to the Keycloak server of the session.
+A fresh access token is injected into each request, so that other tools don't
+have to deal with logins and token refreshes. The proxy doesn't require any
+authentication, so make sure it listens on a local address only. Requests must
+be addressed to the listen address or to localhost, requests sent by web pages
+of other origins are rejected.`,
+	Example: `  # Forward requests using the default session
+  proxy
+
+  # Allow read access to the users of realm foo only
+  proxy --read-only --allow /auth/admin/realms/foo/users`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name, _ := cmd.Flags().GetString("session")
+		name = strings.TrimSpace(name)
+
+		listen, _ := cmd.Flags().GetString("listen")
+		listen = strings.TrimSpace(listen)
+		allowedPrefixes, _ := cmd.Flags().GetStringArray("allow")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+
+		//
+		// run proxy
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return cli.Proxy(ctx, container, name, listen, allowedPrefixes, readOnly)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.Flags().StringP("session", "s", "keycloak", "Name of the session to use")
+	proxyCmd.Flags().String("listen", "127.0.0.1:8088", "Address to listen on")
+	proxyCmd.Flags().StringArray("allow", []string{}, "Allowed path prefix, can be specified multiple times (default: all paths)")
+	proxyCmd.Flags().Bool("read-only", false, "Only forward GET, HEAD and OPTIONS requests")
+}
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	keycli "github.com/aisbergg/keycli/pkg"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/interface/cli"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -13,33 +20,145 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "keycli",
 	Short: "A command line interface for Keycloak",
-	Long:  `Keycli is a CLI program for Keycloak. It makes use of Keycloaks built-in REST API to query information and execute commands over HTTPS. This allows the tool to list/add/update/delete users/groups/clients/roles conveniently from the command line. Thus it can be used as a replacement for the clumsy web-ui for the most common management operations.`,
+	Long: `Keycli is a CLI program for Keycloak. It makes use of Keycloaks built-in REST API to query information and execute commands over HTTPS. This allows the tool to list/add/update/delete users/groups/clients/roles conveniently from the command line. Thus it can be used as a replacement for the clumsy web-ui for the most common management operations.
+
+Exit codes:
+  0    success
+  1    unknown error
+  2    invalid usage or input
+  3    session or resource not found
+  4    session or resource already exists
+  5    not authenticated or session expired
+  6    permission denied
+  7    conflict with the state of a resource
+  8    network error or timeout
+  130  interrupted
+
+With --error-format json, errors are printed to stderr as a JSON document of the form
+{"error": {"kind": "not_found", "message": "...", "exit_code": 3}}.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		//
 		// parse flags and args
 		//
 		printVersion, _ := cmd.Flags().GetBool("version")
 		if printVersion {
-			fmt.Println(keycli.Version)
+			container.Printer.Println(keycli.Version)
 			return
 		}
 	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		runStarted = true
+		errorFormat, _ := cmd.Flags().GetString("error-format")
+		if errorFormat != "text" && errorFormat != "json" {
+			return core.NewError(core.KindValidation, "unknown error format '%s', expected text or json", errorFormat)
+		}
+		requestTimeout, _ := cmd.Flags().GetDuration("request-timeout")
+		if requestTimeout < 0 {
+			return core.NewError(core.KindValidation, "request timeout must not be negative")
+		}
+		maxRetries, _ := cmd.Flags().GetInt("max-retries")
+		if maxRetries < 0 {
+			return core.NewError(core.KindValidation, "number of retries must not be negative")
+		}
+		rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+		if rateLimit < 0 {
+			return core.NewError(core.KindValidation, "rate limit must not be negative")
+		}
+		debug, _ := cmd.Flags().GetBool("debug")
+		trace, _ := cmd.Flags().GetBool("trace")
+		traceFile, _ := cmd.Flags().GetString("trace-file")
+		return container.ConfigureHTTP(cli.HTTPConfig{
+			RequestTimeout: requestTimeout,
+			MaxRetries:     maxRetries,
+			RateLimit:      rateLimit,
+			Debug:          debug,
+			Trace:          trace,
+			TraceFile:      strings.TrimSpace(traceFile),
+		})
+	},
 }
 
+// container holds the dependencies of the commands.
+var container = cli.NewContainer()
+
+// runStarted is set, once a command passed the parsing of flags and arguments.
+// Errors before that are usage errors.
+var runStarted bool
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
-	cmd, err := rootCmd.ExecuteC()
+	// the first SIGINT or SIGTERM cancels the running command, so that it can
+	// stop cleanly. Any further signal terminates the program immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
-		debug, _ := cmd.Flags().GetBool("debug")
-		errMsg := formatError(err, debug)
-		fmt.Fprintln(os.Stderr, errMsg)
-		os.Exit(1)
+		if !runStarted && core.KindOf(err) == core.KindUnknown {
+			err = core.WrapError(core.KindValidation, err)
+		}
+		// persistent flags are shared with the executed sub command
+		debug, _ := rootCmd.PersistentFlags().GetBool("debug")
+		errorFormat, _ := rootCmd.PersistentFlags().GetString("error-format")
+		// secrets must never be echoed, not even in debug output
+		errMsg := cli.Redact(formatError(err, debug))
+		if errorFormat == "json" {
+			errMsg = cli.ErrorJSON(err, errMsg)
+		}
+		fmt.Fprintln(container.Printer.Err, errMsg)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
 func init() {
 	rootCmd.Flags().Bool("version", false, "Print program version and quit")
 	rootCmd.PersistentFlags().Bool("debug", false, "Turn on debug mode (verbose output and stack traces)")
+	rootCmd.PersistentFlags().String("error-format", "text", "Format of error messages on stderr: text or json")
+	rootCmd.PersistentFlags().Bool("trace", false, "Dump every request to and response from Keycloak to stderr, credentials are redacted")
+	rootCmd.PersistentFlags().String("trace-file", "", "Write the dump of --trace to a file instead of stderr (e.g.: to attach it to a bug report)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration of the whole command (e.g.: 5m), 0 means no limit")
+	rootCmd.PersistentFlags().Duration("request-timeout", 15*time.Second, "Maximum duration of a single request to Keycloak, 0 means no limit")
+	rootCmd.PersistentFlags().Int("max-retries", 3, "Maximum number of retries of a failed request to Keycloak")
+	rootCmd.PersistentFlags().Float64("rate-limit", 0, "Maximum number of requests to Keycloak per second, 0 means no limit")
+}
+
+// addRealmFlag adds the --realm flag to a command managing resources, which is
+// inherited by all its subcommands.
+func addRealmFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("realm", "", "Realm to manage (defaults to the target realm of the session)")
+}
+
+// realmFlag returns the realm given by --realm. An empty realm stands for the
+// target realm of the session, it is resolved by `core.Session.ResolveRealm`
+// once the session is loaded.
+func realmFlag(cmd *cobra.Command) string {
+	realm, _ := cmd.Flags().GetString("realm")
+	return strings.TrimSpace(realm)
+}
+
+// printResult prints the result of a command, unless the command failed.
+func printResult(result cli.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	return result.Print(container.Printer)
+}
+
+// commandContext returns the context for running a command. The context is
+// canceled on SIGINT or SIGTERM and after the duration given by --timeout.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 func formatError(err error, debug bool) string {
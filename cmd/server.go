@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Show the Keycloak server information and capabilities",
+	Long: `Show the Keycloak server information and capabilities.
+
+The base path and version of the server are detected on login. Legacy WildFly
+based servers serve their endpoints below '/auth', Quarkus based servers use no
+prefix by default. The version determines, which features of the server can be
+used. If the version is unknown, all features are assumed to be available.`,
+	Example: `  # Show the server of the default session
+  server
+
+  # Show the server of the session baz
+  server -s baz`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name, _ := cmd.Flags().GetString("session")
+		name = strings.TrimSpace(name)
+
+		//
+		// show server information
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.Server(ctx, container, name))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serverCmd)
+	serverCmd.Flags().StringP("session", "s", "keycloak", "Name of the session to use")
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// sessionsCmd represents the base command for managing stored sessions.
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage stored sessions",
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Validate all stored sessions",
+	Long: `Validate all stored sessions.
+
+Every stored session is loaded, which migrates session files written by older
+versions of keycli, and validated. Sessions that cannot be loaded are reported
+and the command exits with an error.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		// the report is printed, even if some sessions are broken
+		result, err := cli.SessionDoctor(ctx, container)
+		if result != nil {
+			if printErr := result.Print(container.Printer); err == nil {
+				err = printErr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsDoctorCmd)
+}
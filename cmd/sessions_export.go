@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export [SESSION]",
+	Short: "Export a session for another machine or the stateless mode",
+	Long: `Export a session for another machine or the stateless mode.
+
+The session is refreshed, if required, and written as base64 encoded blob to
+stdout or the file given by --out. The blob can be imported on another machine
+using the 'sessions import' command. With --encrypt the blob is encrypted with
+a passphrase, which is required for the import. With --access-only the refresh
+token is omitted, so that the imported session expires together with its
+access token.
+
+When an unencrypted blob is passed in the environment variable
+KEYCLI_SESSION_DATA, keycli keeps the session in memory only and doesn't read
+or write any session files. This is intended for CI environments.
+
+Alternatively, the stateless mode is used if KEYCLI_CLIENT_ID and
+KEYCLI_CLIENT_SECRET are set. In that case keycli logs in using the client
+credentials on the fly. KEYCLI_URL, KEYCLI_REALM (default: master) and
+KEYCLI_SKIP_VERIFY configure the login.
+
+Unless encrypted, the blob contains the tokens of the session in plain text.
+Treat it like a password. If the server rotates refresh tokens, the exported
+and the original session cannot be refreshed independently.`,
+	Example: `  # Export the default session and store it as CI secret
+  sessions export | gh secret set KEYCLI_SESSION_DATA
+
+  # Export the session baz encrypted into a file
+  sessions export baz --encrypt --out baz.session`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name := "keycloak"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		name = strings.TrimSpace(name)
+
+		outPath, _ := cmd.Flags().GetString("out")
+		outPath = strings.TrimSpace(outPath)
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+		accessOnly, _ := cmd.Flags().GetBool("access-only")
+
+		//
+		// export session
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.SessionExport(ctx, container, name, outPath, encrypt, accessOnly))
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsExportCmd)
+	sessionsExportCmd.Flags().StringP("out", "o", "", "File to write the exported session to (default: stdout)")
+	sessionsExportCmd.Flags().Bool("encrypt", false, "Encrypt the exported session with a passphrase")
+	sessionsExportCmd.Flags().Bool("access-only", false, "Omit the refresh token")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import a session exported on another machine",
+	Long: `Import a session exported on another machine.
+
+The FILE must contain a session exported by the 'sessions export' command. Use
+'-' to read it from stdin. The passphrase is asked for, if the session is
+encrypted. Invalid and expired sessions are refused.`,
+	Example: `  # Import a session and name it baz
+  sessions import --name baz baz.session
+
+  # Transfer the default session to a jump host
+  keycli sessions export | ssh jumphost keycli sessions import -`,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		path := strings.TrimSpace(args[0])
+		name, _ := cmd.Flags().GetString("name")
+		name = strings.TrimSpace(name)
+		force, _ := cmd.Flags().GetBool("force")
+
+		//
+		// import session
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.SessionImport(ctx, container, path, name, force))
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsImportCmd)
+	sessionsImportCmd.Flags().String("name", "", "Name of the imported session (default: name of the exported session)")
+	sessionsImportCmd.Flags().BoolP("force", "f", false, "Overwrite an existing session with the same name")
+}
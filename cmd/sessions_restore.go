@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsRestoreCmd = &cobra.Command{
+	Use:   "restore [SESSION]",
+	Short: "Restore the last good state of a corrupted session",
+	Long: `Restore the last good state of a corrupted session.
+
+Every time a session is written, the previous state is kept as backup. If the
+session file gets corrupted, e.g. by a full disk, the backup can be restored.
+The restored tokens might have been superseded already, in which case you have
+to login again.`,
+	Example: `  # Restore the default session
+  sessions restore`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name := "keycloak"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		name = strings.TrimSpace(name)
+
+		//
+		// restore session
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.SessionRestore(ctx, container, name))
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsRestoreCmd)
+}
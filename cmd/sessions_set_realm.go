@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsSetRealmCmd = &cobra.Command{
+	Use:   "set-realm SESSION [REALM]",
+	Short: "Set the realm managed by default with a session",
+	Long: `Set the realm managed by default with a session.
+
+The login realm of a session can differ from the realm being managed. An admin
+logged into the master realm can manage any other realm. The target realm is
+used by all resource commands, unless overridden by --realm. Omit the REALM to
+manage the login realm again.`,
+	Example: `  # Manage the realm customer-a with the default session
+  sessions set-realm keycloak customer-a
+
+  # Manage the login realm again
+  sessions set-realm keycloak`,
+	Args:          cobra.RangeArgs(1, 2),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name := strings.TrimSpace(args[0])
+		realm := ""
+		if len(args) > 1 {
+			realm = strings.TrimSpace(args[1])
+		}
+
+		//
+		// set target realm
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.SessionSetRealm(ctx, container, name, realm))
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsSetRealmCmd)
+}
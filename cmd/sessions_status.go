@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var sessionsStatusCmd = &cobra.Command{
+	Use:   "status [SESSION]",
+	Short: "Show the status of a session",
+	Long: `Show the status of a session.
+
+The expiry times are taken from the claims of the access and refresh tokens.
+With --verify the signature of the access token is additionally verified
+against the keys published by the realm.`,
+	Example: `  # Show the status of the default session
+  sessions status
+
+  # Show the status of the session baz and verify its access token
+  sessions status --verify baz`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name := "keycloak"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		name = strings.TrimSpace(name)
+
+		verify, _ := cmd.Flags().GetBool("verify")
+
+		//
+		// show status
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.SessionStatus(ctx, container, name, verify))
+	},
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsStatusCmd)
+	sessionsStatusCmd.Flags().Bool("verify", false, "Verify the signature of the access token against the realm keys")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print the access token of a session",
+	Long: `Print the access token of a session.
+
+The access token is refreshed beforehand, if it remains valid for less than the
+time given by --min-validity. Only the token is printed, so that it can be used
+in scripts or by other tools like curl.`,
+	Example: `  # Call an admin endpoint with curl
+  curl -H "$(keycli token --header)" https://sso.example.org/auth/admin/realms/master/users
+
+  # Export the token of session baz into the environment
+  eval "$(keycli token -s baz --format env)"
+
+  # Get a token that remains valid for at least 5 minutes
+  token --min-validity 5m
+
+  # Use keycli as credential plugin for kubectl (in the users section of a kubeconfig)
+  exec:
+    apiVersion: client.authentication.k8s.io/v1
+    command: keycli
+    args: [token, --session, k8s, --exec-credential]
+    interactiveMode: Never`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name, _ := cmd.Flags().GetString("session")
+		name = strings.TrimSpace(name)
+
+		minValidity, _ := cmd.Flags().GetDuration("min-validity")
+		header, _ := cmd.Flags().GetBool("header")
+		format, _ := cmd.Flags().GetString("format")
+		format = strings.TrimSpace(format)
+		execCredential, _ := cmd.Flags().GetBool("exec-credential")
+		if execCredential {
+			if cmd.Flags().Changed("format") {
+				return core.NewError(core.KindValidation, "--exec-credential cannot be combined with --format")
+			}
+			format = "exec-credential"
+		}
+
+		//
+		// print token
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		return printResult(cli.Token(ctx, container, name, minValidity, header, format))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.Flags().StringP("session", "s", "keycloak", "Name of the session to use")
+	tokenCmd.Flags().Duration("min-validity", core.DefaultMinValidity, "Refresh the access token, if it remains valid for less than the given time")
+	tokenCmd.Flags().Bool("header", false, "Print the token as HTTP Authorization header")
+	tokenCmd.Flags().StringP("format", "m", "raw", "Output format (raw, env)")
+	tokenCmd.Flags().Bool("exec-credential", false, "Print the ID token as Kubernetes ExecCredential (client.authentication.k8s.io/v1)")
+}
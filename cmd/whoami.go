@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the identity and roles of a session",
+	Long: `Show the identity and roles of a session.
+
+The information is decoded from the access token of the session. It includes
+the realm roles and the client roles of the 'realm-management' client, which
+grant the permissions for administering a realm. Sessions of the master realm
+also hold these roles in the client named after the target realm (e.g.:
+'foo-realm').
+
+With --check the command exits with an error, if a role is neither granted as
+realm role nor as one of these client roles. This allows scripts to fail fast
+before attempting an admin operation.`,
+	Example: `  # Show who is logged in with the default session
+  whoami
+
+  # Make sure the session baz is allowed to manage users
+  whoami -s baz --check manage-users`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		//
+		// parse flags and args
+		//
+		name, _ := cmd.Flags().GetString("session")
+		name = strings.TrimSpace(name)
+		checkRoles, _ := cmd.Flags().GetStringArray("check")
+
+		//
+		// show identity
+		//
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		// the identity is printed, even if roles are missing
+		result, err := cli.Whoami(ctx, container, name, checkRoles)
+		if result != nil {
+			if printErr := result.Print(container.Printer); err == nil {
+				err = printErr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+	whoamiCmd.Flags().StringP("session", "s", "keycloak", "Name of the session to use")
+	whoamiCmd.Flags().StringArray("check", []string{}, "Role, that must be granted, can be specified multiple times")
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+)
+
+// Do sends a raw request to the admin REST API. HTTP error responses aren't
+// treated as errors. The path is relative to the admin REST API root (e.g.:
+// realms/master/users).
+func (c *Client) Do(ctx context.Context, req keycloak.APIRequest) (*keycloak.APIResponse, error) {
+	session, _, err := c.prepare(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return keycloak.NewClient(*session, c.http).Do(ctx, req)
+}
+
+// DoPaginated sends a raw GET request to a list endpoint of the admin REST API
+// and merges the JSON arrays of all pages into a single one.
+func (c *Client) DoPaginated(ctx context.Context, req keycloak.APIRequest, pageSize int) (*keycloak.APIResponse, error) {
+	session, _, err := c.prepare(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return keycloak.NewClient(*session, c.http).DoPaginated(ctx, req, pageSize)
+}
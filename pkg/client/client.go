@@ -0,0 +1,143 @@
+// Package client is a Go library for administering Keycloak using the sessions
+// of keycli. Access tokens are refreshed automatically before they expire.
+//
+// A client is created from the name of a stored session:
+//
+//	c, err := client.New(ctx, nil, "prod", keycloak.DefaultHTTPOptions())
+//	if err != nil {
+//		return err
+//	}
+//	users, err := c.Users.List(ctx, "", core.ListOptions{Search: "jane"})
+//
+// An empty realm refers to the target realm of the session.
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+)
+
+// Client administers the realms of a Keycloak server using a session.
+type Client struct {
+	// Users manages the users of a realm.
+	Users *UserService
+	// Groups manages the groups of a realm.
+	Groups *GroupService
+	// Roles manages the realm and client roles.
+	Roles *RoleService
+	// Clients manages the clients of a realm.
+	Clients *ClientService
+
+	service  core.SessionService
+	provider core.AdminProvider
+	http     keycloak.HTTPOptions
+	// name is the name of the stored session. It is empty, if the client was
+	// created from a session value.
+	name    string
+	mu      sync.Mutex
+	session *core.Session
+}
+
+// listPageSize is the number of results requested per page by the `ListAll`
+// methods.
+const listPageSize = 100
+
+// New creates a client using a stored session. Refreshed tokens are written
+// back to the session. If service is nil, the sessions are loaded from the
+// session files of keycli. The requests to Keycloak are sent using the given
+// options.
+func New(ctx context.Context, service core.SessionService, name string, opts keycloak.HTTPOptions) (*Client, error) {
+	if service == nil {
+		service = defaultSessionService(opts)
+	}
+	session, err := service.LoadRefresh(ctx, name, core.DefaultMinValidity)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(service, session, opts)
+	c.name = name
+	return c, nil
+}
+
+// NewFromSession creates a client using a session value, which is not stored
+// (e.g.: an imported one). Refreshed tokens are kept in memory only. If service
+// is nil, a service using the session files of keycli is used. The requests to
+// Keycloak are sent using the given options.
+func NewFromSession(service core.SessionService, session core.Session, opts keycloak.HTTPOptions) *Client {
+	if service == nil {
+		service = defaultSessionService(opts)
+	}
+	return newClient(service, &session, opts)
+}
+
+// defaultSessionService creates a session service, that uses the session files
+// of keycli.
+func defaultSessionService(opts keycloak.HTTPOptions) core.SessionService {
+	return core.NewSessionService(jsonfile.NewJSONFileSessionRepository(), keycloak.NewKeycloakSessionProvider(opts))
+}
+
+func newClient(service core.SessionService, session *core.Session, opts keycloak.HTTPOptions) *Client {
+	c := &Client{
+		service:  service,
+		provider: keycloak.NewKeycloakAdminProvider(opts),
+		http:     opts,
+		session:  session,
+	}
+	c.Users = &UserService{client: c}
+	c.Groups = &GroupService{client: c}
+	c.Roles = &RoleService{client: c}
+	c.Clients = &ClientService{client: c}
+	return c
+}
+
+// Session returns a copy of the current session.
+func (c *Client) Session() core.Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.session
+}
+
+// prepare returns a session, whose access token is valid for at least
+// `core.DefaultMinValidity`, and resolves the realm of a request.
+func (c *Client) prepare(ctx context.Context, realm string) (*core.Session, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session.IsExpired(core.DefaultMinValidity) {
+		if c.name != "" {
+			// another process might have refreshed the stored session already
+			session, err := c.service.LoadRefresh(ctx, c.name, core.DefaultMinValidity)
+			if err != nil {
+				return nil, "", err
+			}
+			c.session = session
+		} else if _, err := c.service.Refresh(ctx, c.session, core.DefaultMinValidity); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// the session is copied, so that a concurrent refresh doesn't interfere
+	// with the running request
+	session := *c.session
+	return &session, session.ResolveRealm(realm), nil
+}
+
+// paginate calls list for consecutive pages, until a page isn't full. The
+// function returns the number of results of the requested page.
+func paginate(opts core.ListOptions, list func(opts core.ListOptions) (int, error)) error {
+	opts.Max = listPageSize
+	for {
+		n, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if n < opts.Max {
+			return nil
+		}
+		opts.First += n
+	}
+}
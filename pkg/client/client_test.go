@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+// fakeSessionService serves a single session. Methods not overridden panic.
+type fakeSessionService struct {
+	core.SessionService
+	session     *core.Session
+	loadRefresh int
+}
+
+func (f *fakeSessionService) LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*core.Session, error) {
+	f.loadRefresh++
+	session := *f.session
+	return &session, nil
+}
+
+// fakeAdminProvider serves a fixed list of users. Methods not overridden
+// panic.
+type fakeAdminProvider struct {
+	core.AdminProvider
+	users []core.User
+	calls []core.ListOptions
+	realm string
+	token string
+}
+
+func (f *fakeAdminProvider) ListUsers(ctx context.Context, session *core.Session, realm string, opts core.ListOptions) ([]core.User, error) {
+	f.calls = append(f.calls, opts)
+	f.realm = realm
+	f.token = session.Token.AccessToken
+	users := []core.User{}
+	for _, user := range f.users {
+		if opts.Exact && user.Username != opts.Search {
+			continue
+		}
+		users = append(users, user)
+	}
+	if opts.First >= len(users) {
+		return []core.User{}, nil
+	}
+	users = users[opts.First:]
+	if opts.Max > 0 && len(users) > opts.Max {
+		users = users[:opts.Max]
+	}
+	return users, nil
+}
+
+// newTestSession returns a session, whose access token expires in the given
+// time span.
+func newTestSession(t *testing.T, expiresIn time.Duration) *core.Session {
+	claims := jwt.StandardClaims{ExpiresAt: jwt.At(time.Now().Add(expiresIn))}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &core.Session{Name: "test", Realm: "master", TargetRealm: "demo"}
+	session.Token.AccessToken = token
+	return session
+}
+
+func newTestClient(t *testing.T, session *core.Session, users []core.User) (*Client, *fakeSessionService, *fakeAdminProvider) {
+	service := &fakeSessionService{session: session}
+	c, err := New(context.Background(), service, session.Name, keycloak.HTTPOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &fakeAdminProvider{users: users}
+	c.provider = provider
+	return c, service, provider
+}
+
+func TestGetByUsername(t *testing.T) {
+	users := []core.User{{ID: "1", Username: "janet"}, {ID: "2", Username: "jane"}}
+	c, _, provider := newTestClient(t, newTestSession(t, time.Hour), users)
+
+	user, err := c.Users.GetByUsername(context.Background(), "", "jane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "2" {
+		t.Errorf("got user %s, want 2", user.ID)
+	}
+	if len(provider.calls) != 1 || !provider.calls[0].Exact {
+		t.Errorf("users were not searched exactly: %+v", provider.calls)
+	}
+	if provider.realm != "demo" {
+		t.Errorf("realm = %s, want the target realm demo", provider.realm)
+	}
+
+	_, err = c.Users.GetByUsername(context.Background(), "", "jan")
+	if core.KindOf(err) != core.KindNotFound {
+		t.Errorf("kind of error = %s, want %s", core.KindOf(err), core.KindNotFound)
+	}
+	_, err = c.Users.GetByUsername(context.Background(), "", "")
+	if core.KindOf(err) != core.KindValidation {
+		t.Errorf("kind of error = %s, want %s", core.KindOf(err), core.KindValidation)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	users := make([]core.User, 2*listPageSize+50)
+	c, _, provider := newTestClient(t, newTestSession(t, time.Hour), users)
+
+	all, err := c.Users.ListAll(context.Background(), "master", core.ListOptions{Max: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(users) {
+		t.Errorf("got %d users, want %d", len(all), len(users))
+	}
+	if len(provider.calls) != 3 {
+		t.Errorf("got %d requests, want 3", len(provider.calls))
+	}
+	for i, call := range provider.calls {
+		if call.First != i*listPageSize || call.Max != listPageSize {
+			t.Errorf("request %d: first = %d, max = %d", i, call.First, call.Max)
+		}
+	}
+	if provider.realm != "master" {
+		t.Errorf("realm = %s, want master", provider.realm)
+	}
+}
+
+func TestRefreshExpiredSession(t *testing.T) {
+	expired := newTestSession(t, -time.Minute)
+	c, service, provider := newTestClient(t, expired, nil)
+	refreshed := newTestSession(t, time.Hour)
+	service.session = refreshed
+
+	if _, err := c.Users.List(context.Background(), "", core.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// once by New and once by the request
+	if service.loadRefresh != 2 {
+		t.Errorf("session was loaded %d times, want 2", service.loadRefresh)
+	}
+	if provider.token != refreshed.Token.AccessToken {
+		t.Error("request didn't use the refreshed access token")
+	}
+
+	if _, err := c.Users.List(context.Background(), "", core.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if service.loadRefresh != 2 {
+		t.Errorf("valid session was loaded again")
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// ClientService manages the clients of a realm.
+type ClientService struct {
+	client *Client
+}
+
+// List returns the clients of a realm. With a search string, only the client
+// with a matching client ID is returned.
+func (s *ClientService) List(ctx context.Context, realm string, opts core.ListOptions) ([]core.Client, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListClients(ctx, session, realm, opts)
+}
+
+// ListAll returns the clients of a realm like `List`, but retrieves all pages
+// starting at `opts.First`. The page size is ignored.
+func (s *ClientService) ListAll(ctx context.Context, realm string, opts core.ListOptions) ([]core.Client, error) {
+	clients := []core.Client{}
+	err := paginate(opts, func(opts core.ListOptions) (int, error) {
+		page, err := s.List(ctx, realm, opts)
+		clients = append(clients, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Get returns a client by its ID.
+func (s *ClientService) Get(ctx context.Context, realm, id string) (*core.Client, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.GetClient(ctx, session, realm, id)
+}
+
+// GetByClientID returns a client by its client ID.
+func (s *ClientService) GetByClientID(ctx context.Context, realm, clientID string) (*core.Client, error) {
+	clients, err := s.List(ctx, realm, core.ListOptions{Search: clientID})
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if client.ClientID == clientID {
+			return &client, nil
+		}
+	}
+	return nil, core.NewError(core.KindNotFound, "client '%s' does not exist", clientID)
+}
+
+// Create creates a client and returns the ID assigned to it.
+func (s *ClientService) Create(ctx context.Context, realm string, client core.Client) (string, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return "", err
+	}
+	return s.client.provider.CreateClient(ctx, session, realm, client)
+}
+
+// Update replaces the attributes of the client with the given ID.
+func (s *ClientService) Update(ctx context.Context, realm string, client core.Client) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.UpdateClient(ctx, session, realm, client)
+}
+
+// Delete deletes a client by its ID.
+func (s *ClientService) Delete(ctx context.Context, realm, id string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.DeleteClient(ctx, session, realm, id)
+}
+
+// Secret returns the secret of a confidential client.
+func (s *ClientService) Secret(ctx context.Context, realm, id string) (string, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return "", err
+	}
+	return s.client.provider.GetClientSecret(ctx, session, realm, id)
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// GroupService manages the groups of a realm.
+type GroupService struct {
+	client *Client
+}
+
+// List returns the top level groups of a realm including their sub groups.
+// Groups are searched by name.
+func (s *GroupService) List(ctx context.Context, realm string, opts core.ListOptions) ([]core.Group, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListGroups(ctx, session, realm, opts)
+}
+
+// ListAll returns the groups of a realm like `List`, but retrieves all pages
+// starting at `opts.First`. The page size is ignored.
+func (s *GroupService) ListAll(ctx context.Context, realm string, opts core.ListOptions) ([]core.Group, error) {
+	groups := []core.Group{}
+	err := paginate(opts, func(opts core.ListOptions) (int, error) {
+		page, err := s.List(ctx, realm, opts)
+		groups = append(groups, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Get returns a group by its ID.
+func (s *GroupService) Get(ctx context.Context, realm, id string) (*core.Group, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.GetGroup(ctx, session, realm, id)
+}
+
+// GetByPath returns a group by its path (e.g.: `/staff/admins`).
+func (s *GroupService) GetByPath(ctx context.Context, realm, path string) (*core.Group, error) {
+	path = "/" + strings.Trim(path, "/")
+	names := strings.Split(path, "/")
+	groups, err := s.ListAll(ctx, realm, core.ListOptions{Search: names[len(names)-1]})
+	if err != nil {
+		return nil, err
+	}
+	if group, ok := findGroup(groups, path); ok {
+		return &group, nil
+	}
+	return nil, core.NewError(core.KindNotFound, "group '%s' does not exist", path)
+}
+
+// findGroup searches a tree of groups for the group with the given path.
+func findGroup(groups []core.Group, path string) (core.Group, bool) {
+	for _, group := range groups {
+		if group.Path == path {
+			return group, true
+		}
+		if group, ok := findGroup(group.SubGroups, path); ok {
+			return group, true
+		}
+	}
+	return core.Group{}, false
+}
+
+// Create creates a top level group and returns the ID assigned to it.
+func (s *GroupService) Create(ctx context.Context, realm string, group core.Group) (string, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return "", err
+	}
+	return s.client.provider.CreateGroup(ctx, session, realm, group)
+}
+
+// Update replaces the attributes of the group with the given ID.
+func (s *GroupService) Update(ctx context.Context, realm string, group core.Group) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.UpdateGroup(ctx, session, realm, group)
+}
+
+// Delete deletes a group including its sub groups.
+func (s *GroupService) Delete(ctx context.Context, realm, id string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.DeleteGroup(ctx, session, realm, id)
+}
+
+// Members returns the members of a group.
+func (s *GroupService) Members(ctx context.Context, realm, id string, opts core.ListOptions) ([]core.User, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListGroupMembers(ctx, session, realm, id, opts)
+}
+
+// AllMembers returns the members of a group like `Members`, but retrieves all
+// pages starting at `opts.First`. The page size is ignored.
+func (s *GroupService) AllMembers(ctx context.Context, realm, id string, opts core.ListOptions) ([]core.User, error) {
+	users := []core.User{}
+	err := paginate(opts, func(opts core.ListOptions) (int, error) {
+		page, err := s.Members(ctx, realm, id, opts)
+		users = append(users, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// RoleService manages the realm roles and reads the roles of clients.
+type RoleService struct {
+	client *Client
+}
+
+// List returns the realm roles.
+func (s *RoleService) List(ctx context.Context, realm string) ([]core.Role, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListRealmRoles(ctx, session, realm)
+}
+
+// Get returns a realm role by its name.
+func (s *RoleService) Get(ctx context.Context, realm, name string) (*core.Role, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.GetRealmRole(ctx, session, realm, name)
+}
+
+// Create creates a realm role.
+func (s *RoleService) Create(ctx context.Context, realm string, role core.Role) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.CreateRealmRole(ctx, session, realm, role)
+}
+
+// Delete deletes a realm role by its name.
+func (s *RoleService) Delete(ctx context.Context, realm, name string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.DeleteRealmRole(ctx, session, realm, name)
+}
+
+// UserRoles returns the realm roles directly granted to a user.
+func (s *RoleService) UserRoles(ctx context.Context, realm, userID string) ([]core.Role, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListUserRealmRoles(ctx, session, realm, userID)
+}
+
+// Grant grants realm roles given by their names to a user.
+func (s *RoleService) Grant(ctx context.Context, realm, userID string, names ...string) error {
+	roles, err := s.lookup(ctx, realm, names)
+	if err != nil {
+		return err
+	}
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.GrantRealmRoles(ctx, session, realm, userID, roles)
+}
+
+// Revoke revokes realm roles given by their names from a user.
+func (s *RoleService) Revoke(ctx context.Context, realm, userID string, names ...string) error {
+	roles, err := s.lookup(ctx, realm, names)
+	if err != nil {
+		return err
+	}
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.RevokeRealmRoles(ctx, session, realm, userID, roles)
+}
+
+// lookup retrieves realm roles by their names, because Keycloak identifies
+// roles by their IDs when granting them.
+func (s *RoleService) lookup(ctx context.Context, realm string, names []string) ([]core.Role, error) {
+	roles := make([]core.Role, 0, len(names))
+	for _, name := range names {
+		role, err := s.Get(ctx, realm, name)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, nil
+}
+
+// ClientRoles returns the roles of a client. The client is identified by its
+// ID, not the client ID.
+func (s *RoleService) ClientRoles(ctx context.Context, realm, clientID string) ([]core.Role, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListClientRoles(ctx, session, realm, clientID)
+}
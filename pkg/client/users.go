@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// UserService manages the users of a realm.
+type UserService struct {
+	client *Client
+}
+
+// List returns the users of a realm. Users are searched by username, name and
+// email.
+func (s *UserService) List(ctx context.Context, realm string, opts core.ListOptions) ([]core.User, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListUsers(ctx, session, realm, opts)
+}
+
+// Get returns a user by its ID.
+func (s *UserService) Get(ctx context.Context, realm, id string) (*core.User, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.GetUser(ctx, session, realm, id)
+}
+
+// ListAll returns the users of a realm like `List`, but retrieves all pages
+// starting at `opts.First`. The page size is ignored.
+func (s *UserService) ListAll(ctx context.Context, realm string, opts core.ListOptions) ([]core.User, error) {
+	users := []core.User{}
+	err := paginate(opts, func(opts core.ListOptions) (int, error) {
+		page, err := s.List(ctx, realm, opts)
+		users = append(users, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetByUsername returns a user by its username.
+func (s *UserService) GetByUsername(ctx context.Context, realm, username string) (*core.User, error) {
+	if username == "" {
+		return nil, core.NewError(core.KindValidation, "username must not be empty")
+	}
+	users, err := s.List(ctx, realm, core.ListOptions{Search: username, Exact: true})
+	if err != nil {
+		return nil, err
+	}
+	// usernames are stored in lower case, but may be given in any case
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+	return nil, core.NewError(core.KindNotFound, "user '%s' does not exist", username)
+}
+
+// Create creates a user and returns the ID assigned to it.
+func (s *UserService) Create(ctx context.Context, realm string, user core.User) (string, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return "", err
+	}
+	return s.client.provider.CreateUser(ctx, session, realm, user)
+}
+
+// Update replaces the attributes of the user with the given ID. Use `Get` to
+// retrieve the current attributes first.
+func (s *UserService) Update(ctx context.Context, realm string, user core.User) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.UpdateUser(ctx, session, realm, user)
+}
+
+// Delete deletes a user by its ID.
+func (s *UserService) Delete(ctx context.Context, realm, id string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.DeleteUser(ctx, session, realm, id)
+}
+
+// SetPassword sets the password of a user. A temporary password must be
+// changed on the next login.
+func (s *UserService) SetPassword(ctx context.Context, realm, id, password string, temporary bool) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.SetPassword(ctx, session, realm, id, password, temporary)
+}
+
+// Groups returns the groups, that a user is a member of.
+func (s *UserService) Groups(ctx context.Context, realm, id string) ([]core.Group, error) {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.provider.ListUserGroups(ctx, session, realm, id)
+}
+
+// AddToGroup makes a user a member of a group.
+func (s *UserService) AddToGroup(ctx context.Context, realm, userID, groupID string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.AddUserToGroup(ctx, session, realm, userID, groupID)
+}
+
+// RemoveFromGroup removes a user from a group.
+func (s *UserService) RemoveFromGroup(ctx context.Context, realm, userID, groupID string) error {
+	session, realm, err := s.client.prepare(ctx, realm)
+	if err != nil {
+		return err
+	}
+	return s.client.provider.RemoveUserFromGroup(ctx, session, realm, userID, groupID)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// User is a user of a realm.
+type User struct {
+	ID              string              `json:"id"`
+	Username        string              `json:"username"`
+	Email           string              `json:"email"`
+	EmailVerified   bool                `json:"email_verified"`
+	FirstName       string              `json:"first_name"`
+	LastName        string              `json:"last_name"`
+	Enabled         bool                `json:"enabled"`
+	Created         time.Time           `json:"created"`
+	RequiredActions []string            `json:"required_actions"`
+	Attributes      map[string][]string `json:"attributes"`
+}
+
+// Group is a group of a realm. Groups are organized in a tree.
+type Group struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Path       string              `json:"path"`
+	SubGroups  []Group             `json:"sub_groups"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// Role is a realm role or a role of a client.
+type Role struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Composite   bool   `json:"composite"`
+	ClientRole  bool   `json:"client_role"`
+	// ContainerID is the ID of the realm or client, that the role belongs to.
+	ContainerID string `json:"container_id"`
+}
+
+// Client is a client of a realm. The ID is assigned by Keycloak, whereas the
+// client ID is the name used in the protocols.
+type Client struct {
+	ID                     string   `json:"id"`
+	ClientID               string   `json:"client_id"`
+	Name                   string   `json:"name"`
+	Description            string   `json:"description"`
+	Protocol               string   `json:"protocol"`
+	Enabled                bool     `json:"enabled"`
+	PublicClient           bool     `json:"public_client"`
+	ServiceAccountsEnabled bool     `json:"service_accounts_enabled"`
+	RootURL                string   `json:"root_url"`
+	RedirectURIs           []string `json:"redirect_uris"`
+}
+
+// ListOptions restrict the results of list operations. The zero value returns
+// the first page of the default size of the server, which is 100 for users in
+// Keycloak. The `ListAll` methods of the client retrieve all pages.
+type ListOptions struct {
+	// Search is a search string. Its meaning depends on the listed resource.
+	Search string
+	// Exact requires the search string to match the username exactly instead
+	// of any part of the username, name or email. It applies to users only.
+	Exact bool
+	// First is the index of the first result.
+	First int
+	// Max is the maximum number of results. A value of zero means the default
+	// of the server.
+	Max int
+}
+
+// AdminProvider administers the resources of a realm using the access token
+// of a session. The session must be valid, it isn't refreshed.
+type AdminProvider interface {
+	// ListUsers returns the users of a realm. Users are searched by username,
+	// name and email or, if exact, by username only.
+	ListUsers(ctx context.Context, session *Session, realm string, opts ListOptions) ([]User, error)
+	// GetUser returns a user by its ID.
+	GetUser(ctx context.Context, session *Session, realm, id string) (*User, error)
+	// CreateUser creates a user and returns the ID assigned to it.
+	CreateUser(ctx context.Context, session *Session, realm string, user User) (string, error)
+	// UpdateUser replaces the attributes of the user with the given ID.
+	UpdateUser(ctx context.Context, session *Session, realm string, user User) error
+	// DeleteUser deletes a user.
+	DeleteUser(ctx context.Context, session *Session, realm, id string) error
+	// SetPassword sets the password of a user. A temporary password must be
+	// changed on the next login.
+	SetPassword(ctx context.Context, session *Session, realm, id, password string, temporary bool) error
+	// ListUserGroups returns the groups, that a user is a member of.
+	ListUserGroups(ctx context.Context, session *Session, realm, id string) ([]Group, error)
+	// AddUserToGroup makes a user a member of a group.
+	AddUserToGroup(ctx context.Context, session *Session, realm, userID, groupID string) error
+	// RemoveUserFromGroup removes a user from a group.
+	RemoveUserFromGroup(ctx context.Context, session *Session, realm, userID, groupID string) error
+
+	// ListGroups returns the top level groups of a realm including their sub
+	// groups. Groups are searched by name.
+	ListGroups(ctx context.Context, session *Session, realm string, opts ListOptions) ([]Group, error)
+	// GetGroup returns a group by its ID.
+	GetGroup(ctx context.Context, session *Session, realm, id string) (*Group, error)
+	// CreateGroup creates a top level group and returns the ID assigned to it.
+	CreateGroup(ctx context.Context, session *Session, realm string, group Group) (string, error)
+	// UpdateGroup replaces the attributes of the group with the given ID.
+	UpdateGroup(ctx context.Context, session *Session, realm string, group Group) error
+	// DeleteGroup deletes a group including its sub groups.
+	DeleteGroup(ctx context.Context, session *Session, realm, id string) error
+	// ListGroupMembers returns the members of a group.
+	ListGroupMembers(ctx context.Context, session *Session, realm, id string, opts ListOptions) ([]User, error)
+
+	// ListRealmRoles returns the realm roles.
+	ListRealmRoles(ctx context.Context, session *Session, realm string) ([]Role, error)
+	// GetRealmRole returns a realm role by its name.
+	GetRealmRole(ctx context.Context, session *Session, realm, name string) (*Role, error)
+	// CreateRealmRole creates a realm role.
+	CreateRealmRole(ctx context.Context, session *Session, realm string, role Role) error
+	// DeleteRealmRole deletes a realm role by its name.
+	DeleteRealmRole(ctx context.Context, session *Session, realm, name string) error
+	// ListUserRealmRoles returns the realm roles directly granted to a user.
+	ListUserRealmRoles(ctx context.Context, session *Session, realm, userID string) ([]Role, error)
+	// GrantRealmRoles grants realm roles to a user.
+	GrantRealmRoles(ctx context.Context, session *Session, realm, userID string, roles []Role) error
+	// RevokeRealmRoles revokes realm roles from a user.
+	RevokeRealmRoles(ctx context.Context, session *Session, realm, userID string, roles []Role) error
+	// ListClientRoles returns the roles of a client. The client is identified
+	// by its ID, not the client ID.
+	ListClientRoles(ctx context.Context, session *Session, realm, id string) ([]Role, error)
+
+	// ListClients returns the clients of a realm. With a search string, only
+	// clients with a matching client ID are returned.
+	ListClients(ctx context.Context, session *Session, realm string, opts ListOptions) ([]Client, error)
+	// GetClient returns a client by its ID.
+	GetClient(ctx context.Context, session *Session, realm, id string) (*Client, error)
+	// CreateClient creates a client and returns the ID assigned to it.
+	CreateClient(ctx context.Context, session *Session, realm string, client Client) (string, error)
+	// UpdateClient replaces the attributes of the client with the given ID.
+	UpdateClient(ctx context.Context, session *Session, realm string, client Client) error
+	// DeleteClient deletes a client by its ID.
+	DeleteClient(ctx context.Context, session *Session, realm, id string) error
+	// GetClientSecret returns the secret of a confidential client.
+	GetClientSecret(ctx context.Context, session *Session, realm, id string) (string, error)
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorKind classifies an error, so that callers can react to it.
+type ErrorKind string
+
+// Error kinds
+const (
+	// KindUnknown is the kind of all unclassified errors.
+	KindUnknown ErrorKind = "unknown"
+	// KindValidation means the input is invalid.
+	KindValidation ErrorKind = "validation"
+	// KindNotFound means a resource or session does not exist.
+	KindNotFound ErrorKind = "not_found"
+	// KindAlreadyExists means a resource or session exists already.
+	KindAlreadyExists ErrorKind = "already_exists"
+	// KindUnauthorized means the credentials are invalid or the session is
+	// expired.
+	KindUnauthorized ErrorKind = "unauthorized"
+	// KindForbidden means the session lacks the permission for an operation.
+	KindForbidden ErrorKind = "forbidden"
+	// KindConflict means an operation conflicts with the state of a resource.
+	KindConflict ErrorKind = "conflict"
+	// KindNetwork means the server couldn't be reached or didn't answer in
+	// time.
+	KindNetwork ErrorKind = "network"
+	// KindInterrupted means the operation was canceled (e.g.: by Ctrl-C).
+	KindInterrupted ErrorKind = "interrupted"
+)
+
+// Error is an error of a certain kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError creates a new error of the given kind.
+func NewError(kind ErrorKind, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: errors.Errorf(format, args...)}
+}
+
+// WrapError classifies an error as the given kind. Returns nil, if the error
+// is nil.
+func WrapError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of the first classified error in the chain of
+// wrapped errors. Errors of canceled contexts are classified as interrupted
+// and errors of exceeded deadlines as network errors.
+func KindOf(err error) ErrorKind {
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			return e.Kind
+		}
+		switch err {
+		case context.Canceled:
+			return KindInterrupted
+		case context.DeadlineExceeded:
+			return KindNetwork
+		}
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			err = nil
+		}
+	}
+	return KindUnknown
+}
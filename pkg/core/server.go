@@ -0,0 +1,120 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Server flavours of Keycloak.
+const (
+	// FlavourWildFly is the legacy distribution of Keycloak (up to version 16),
+	// which serves everything below the `/auth` path.
+	FlavourWildFly = "WildFly"
+	// FlavourQuarkus is the distribution of Keycloak since version 17, which
+	// serves everything at the root path by default.
+	FlavourQuarkus = "Quarkus"
+)
+
+// Capability is a feature of Keycloak, that is only available in some server
+// versions.
+type Capability struct {
+	// ID is the identifier of the capability.
+	ID string
+	// Description is a human readable description of the capability.
+	Description string
+	// MinVersion is the first major version of Keycloak with the capability.
+	MinVersion int
+}
+
+// Capability IDs
+const (
+	CapabilityTokenRevocation = "token-revocation"
+	CapabilityClientPolicies  = "client-policies"
+	CapabilityUserProfile     = "user-profile"
+	CapabilityOrganizations   = "organizations"
+)
+
+// Capabilities is the table of all known capabilities.
+var Capabilities = []Capability{
+	{CapabilityTokenRevocation, "Token revocation endpoint (RFC 7009)", 12},
+	{CapabilityClientPolicies, "Client policies and profiles", 15},
+	{CapabilityUserProfile, "Declarative user profile", 24},
+	{CapabilityOrganizations, "Organizations", 25},
+}
+
+// ServerInfo holds the information about a Keycloak server, that are detected
+// on login.
+type ServerInfo struct {
+	// BasePath is the path prefix of all endpoints (e.g.: `/auth`).
+	BasePath string `json:"base_path"`
+	// Version is the version of Keycloak. It is empty, if the version couldn't
+	// be detected.
+	Version string `json:"version"`
+}
+
+// Flavour returns the flavour of the server. The flavour is derived from the
+// version or, if the version is unknown, from the base path.
+func (si ServerInfo) Flavour() string {
+	if major, ok := si.MajorVersion(); ok {
+		if major >= 17 {
+			return FlavourQuarkus
+		}
+		return FlavourWildFly
+	}
+	if si.BasePath == "/auth" {
+		return FlavourWildFly
+	}
+	return FlavourQuarkus
+}
+
+// MajorVersion returns the major version of the server. Returns false, if the
+// version is unknown.
+func (si ServerInfo) MajorVersion() (int, bool) {
+	major, err := strconv.Atoi(strings.SplitN(si.Version, ".", 2)[0])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+// Supports returns whether the server has the given capability. The second
+// return value is false, if this cannot be determined, because the server
+// version is unknown.
+func (si ServerInfo) Supports(id string) (bool, bool) {
+	capability, ok := findCapability(id)
+	if !ok {
+		return false, true
+	}
+	major, ok := si.MajorVersion()
+	if !ok {
+		return false, false
+	}
+	return major >= capability.MinVersion, true
+}
+
+// RequireCapability returns an error, if the server is known to lack the given
+// capability. Servers of an unknown version are assumed to have it.
+func (si ServerInfo) RequireCapability(id string) error {
+	supported, known := si.Supports(id)
+	if !known || supported {
+		return nil
+	}
+	capability, ok := findCapability(id)
+	if !ok {
+		return errors.Errorf("unknown capability '%s'", id)
+	}
+	return NewError(KindValidation, "%s is not supported by Keycloak %s, it requires Keycloak %d or newer",
+		strings.ToLower(capability.Description[:1])+capability.Description[1:], si.Version, capability.MinVersion)
+}
+
+// findCapability looks up a capability in the capability table.
+func findCapability(id string) (Capability, bool) {
+	for _, capability := range Capabilities {
+		if capability.ID == id {
+			return capability, true
+		}
+	}
+	return Capability{}, false
+}
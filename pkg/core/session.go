@@ -1,7 +1,10 @@
 package core
 
 import (
+	"context"
 	"net/url"
+	"path"
+	"regexp"
 	"time"
 
 	"github.com/Nerzal/gocloak/v8"
@@ -9,6 +12,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// DefaultMinValidity is the default time span, for which an access token must
+// remain valid. Access tokens that expire sooner are refreshed.
+const DefaultMinValidity = 60 * time.Second
+
 // -----------------------------------------------------------------------------
 //
 // Interfaces
@@ -25,60 +32,176 @@ type Session struct {
 	Token      gocloak.JWT `json:"token"`
 	Created    jwt.Time    `json:"created_at"`
 	SkipVerify bool        `json:"skip_verify"`
+	Offline    bool        `json:"offline"`
+	Server     ServerInfo  `json:"server"`
+	// TargetRealm is the realm managed by default, if it differs from the
+	// realm used for logging in.
+	TargetRealm string `json:"target_realm"`
+	// Proxy is the URL of the HTTP proxy used for connecting to Keycloak. The
+	// proxy is taken from the environment, if empty.
+	Proxy string `json:"proxy"`
+	// NoProxy disables the use of any HTTP proxy.
+	NoProxy bool `json:"no_proxy"`
+	// GrantType is the OAuth grant, that was used for creating the session. It
+	// is empty for sessions created by older versions of keycli.
+	GrantType string `json:"grant_type"`
+	// AccessOnly marks a session exported without its refresh token. It is
+	// usable until the access token expires and cannot be refreshed.
+	AccessOnly bool `json:"access_only"`
+}
+
+// GrantClientCredentials is the grant type of sessions created using a client
+// secret.
+const GrantClientCredentials = "client_credentials"
+
+// AccessTokenClaims holds the claims of a Keycloak access token.
+type AccessTokenClaims struct {
+	jwt.StandardClaims
+	PreferredUsername string               `json:"preferred_username"`
+	AuthorizedParty   string               `json:"azp"`
+	Scope             string               `json:"scope"`
+	RealmAccess       RoleClaim            `json:"realm_access"`
+	ResourceAccess    map[string]RoleClaim `json:"resource_access"`
+}
+
+// RoleClaim holds the roles granted for a realm or a client.
+type RoleClaim struct {
+	Roles []string `json:"roles"`
+}
+
+// SessionOptions holds the settings that are used to create a new session.
+type SessionOptions struct {
+	Name       string
+	URL        string
+	Realm      string
+	ClientID   string
+	SkipVerify bool
+	// Offline requests an offline token, which isn't bound to the idle timeout
+	// of the SSO session.
+	Offline bool
+	// TargetRealm is the realm managed by default. The login realm is managed,
+	// if empty.
+	TargetRealm string
+	// Proxy is the URL of the HTTP proxy used for connecting to Keycloak. The
+	// proxy is taken from the environment, if empty.
+	Proxy string
+	// NoProxy disables the use of any HTTP proxy.
+	NoProxy bool
 }
 
 // SessionService manages the creation and destruction of Keycloak sessions.
 type SessionService interface {
+	// List returns the names of all stored sessions.
+	List() ([]string, error)
 	// Load loads a session from a stored session.
-	Load(name string) (*Session, error)
-	// LoadRefresh loads and refreshes a session.
-	LoadRefresh(name string, refreshBeforeExpiry bool) (*Session, error)
+	Load(ctx context.Context, name string) (*Session, error)
+	// LoadRefresh loads a session and refreshes it, if the access token
+	// remains valid for less than `minValidity`.
+	LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*Session, error)
 	// CreateWithUsernamePassword creates a new session by loging into a session
 	// provider with username and password. It also writes the newly created
 	// session to a session repository.
-	CreateWithUsernamePassword(name, url, realm, clientID, user, password string, skipVerify bool) (*Session, error)
+	CreateWithUsernamePassword(ctx context.Context, opts SessionOptions, user, password string) (*Session, error)
 	// CreateWithClientSecret creates a new session by loging into a session
 	// provider with a client secret. It also writes the newly created session
 	// to a session repository.
-	CreateWithClientSecret(name, url, realm, clientID, secret string, skipVerify bool) (*Session, error)
-	// Refresh refreshes a session, if it can be refreshed. Returns true, if the
-	// access token was refreshed.
-	Refresh(session *Session, beforeExpiry bool) (bool, error)
-	// End ends the session and removes it from a session repository.
-	End(session *Session, force bool) error
+	CreateWithClientSecret(ctx context.Context, opts SessionOptions, secret string) (*Session, error)
+	// Refresh refreshes a session, if the access token remains valid for less
+	// than `minValidity` and it can be refreshed. Returns true, if the access
+	// token was refreshed.
+	Refresh(ctx context.Context, session *Session, minValidity time.Duration) (bool, error)
+	// End ends the session and removes it from a session repository. With
+	// `revoke` set, the access and refresh tokens are revoked as well. Returns
+	// true, if the tokens were revoked. With `force` set, the session is ended
+	// even if the revocation fails.
+	End(ctx context.Context, session *Session, force, revoke bool) (bool, error)
+	// Remove removes a stored session without ending it. It is meant for
+	// sessions, that cannot be loaded anymore (e.g.: corrupted ones).
+	Remove(ctx context.Context, name string) error
+	// Restore restores the last good state of a corrupted session.
+	Restore(ctx context.Context, name string) error
+	// Import validates a session, that was exported elsewhere, and writes it
+	// to a session repository. Expired sessions are refused.
+	Import(ctx context.Context, session *Session, overwrite bool) error
+	// Verify verifies the signature of the access token against the keys
+	// published by the realm.
+	Verify(ctx context.Context, session *Session) error
+	// SetTargetRealm sets the realm managed by default with a stored session.
+	// An empty realm resets it to the login realm.
+	SetTargetRealm(ctx context.Context, name, realm string) error
+	// Location describes where a session is stored (e.g.: the path of the
+	// session file), so that it can be shown to the user.
+	Location(name string) string
 }
 
 // SessionRepository is used for loading and storing from and to a repository.
-// The access to the repository is managed to be exclusive between multiple
-// processes.
+// The access to the repository is managed between multiple processes: Reading
+// requires shared access, writing requires exclusive access.
 type SessionRepository interface {
 	// Exists indicates that a session with a given name exists or not.
 	Exists(name string) (bool, error)
-	// Open opens the session repository with exclusive access.
-	Open(name string) error
+	// List returns the names of all stored sessions.
+	List() ([]string, error)
+	// Open opens the session repository. With `exclusive` set, the repository
+	// is opened with exclusive access for writing, else with shared access for
+	// reading.
+	Open(ctx context.Context, name string, exclusive bool) error
 	// Close closes the session repository.
 	Close() error
 	// Read reads the content of the session repository.
 	Read() (*Session, error)
 	// Write writes a session to the repository.
 	Write(session *Session) error
+	// Restore restores the last good session, if the stored one is corrupted.
+	Restore() error
 	// Remove removes a stored session repository. Has no effect, if the file
 	// doesn't exist.
 	Remove(name string) error
+	// Location describes where a session is stored (e.g.: the path of the
+	// session file).
+	Location(name string) string
+}
+
+// SessionAgent provides sessions, that are held in memory by a separate agent
+// process. The agent refreshes the sessions proactively and thereby saves
+// other processes from accessing the session repository.
+type SessionAgent interface {
+	// Load loads a session held by the agent.
+	Load(ctx context.Context, name string) (*Session, error)
+	// LoadRefresh loads a session held by the agent, that remains valid for at
+	// least `minValidity`.
+	LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*Session, error)
+	// Forget removes a session from the memory of the agent.
+	Forget(ctx context.Context, name string) error
 }
 
+// ErrSessionCorrupted is returned by a `SessionRepository`, if a stored session
+// is corrupted.
+var ErrSessionCorrupted = errors.New("session is corrupted")
+
+// ErrAgentUnavailable is returned by a `SessionAgent`, if the agent cannot be
+// reached. It is a network error.
+var ErrAgentUnavailable error = &Error{Kind: KindNetwork, Err: errors.New("session agent is unavailable")}
+
 // SessionProvider provides the means to create, refresh and end a session.
 type SessionProvider interface {
 	// CreateWithUsernamePassword creates a new session by logging into the
 	// service provider using a username and password.
-	CreateWithUsernamePassword(name, url, realm, clientID, user, password string, skipVerify bool) (*Session, error)
+	CreateWithUsernamePassword(ctx context.Context, opts SessionOptions, user, password string) (*Session, error)
 	// CreateWithUsernamePassword creates a new session by logging into the
 	// service provider using a client secret.
-	CreateWithClientSecret(name, url, realm, clientID, secret string, skipVerify bool) (*Session, error)
-	// Logout logs out of the session provider and thereby ending a session.
-	End(session *Session) error
+	CreateWithClientSecret(ctx context.Context, opts SessionOptions, secret string) (*Session, error)
+	// Logout logs out of the session provider and thereby ending a session. An
+	// offline session is revoked as well.
+	End(ctx context.Context, session *Session) error
+	// Revoke revokes the access and refresh tokens of a session using the
+	// token revocation endpoint (RFC 7009).
+	Revoke(ctx context.Context, session *Session) error
 	// Refresh refreshes an existing session.
-	Refresh(session *Session) (bool, error)
+	Refresh(ctx context.Context, session *Session) (bool, error)
+	// Verify verifies the signature of the access token against the keys
+	// published by the realm.
+	Verify(ctx context.Context, session *Session) error
 }
 
 // -----------------------------------------------------------------------------
@@ -87,22 +210,98 @@ type SessionProvider interface {
 //
 // -----------------------------------------------------------------------------
 
-// IsExpired returns true, if the access token is expired, else false. This doesn't mean it cannot be refreshed using the refresh token.
-func (s *Session) IsExpired(beforeExpiry bool) bool {
-	now := jwt.Now()
-	accessTokenExpired := s.Created.Add(time.Second * time.Duration(s.Token.ExpiresIn)).After(now.Time)
-	accessTokenExpiresSoon := s.Created.Add(time.Second * time.Duration(s.Token.ExpiresIn-60)).After(now.Time)
-	return accessTokenExpired || (beforeExpiry && accessTokenExpiresSoon)
+// sessionNamePattern matches valid session names. Names are used as file names,
+// therefore path separators are not allowed.
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateSessionName returns an error, if the name cannot be used as the name
+// of a session.
+func ValidateSessionName(name string) error {
+	if !sessionNamePattern.MatchString(name) || name == "." || name == ".." {
+		return NewError(KindValidation, "invalid session name '%s': only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// IsExpired returns true, if the access token is expired or remains valid for
+// less than `minValidity`, else false. This doesn't mean it cannot be refreshed
+// using the refresh token. The expiry is taken from the `exp` claim of the
+// access token.
+func (s *Session) IsExpired(minValidity time.Duration) bool {
+	expiresAt, err := s.AccessTokenExpiry()
+	if err != nil || expiresAt.IsZero() {
+		return true
+	}
+	return !time.Now().Add(minValidity).Before(expiresAt)
 }
 
-// CanBeRefreshed returns true, if the access token can be refreshed using the refresh token, else false.
+// CanBeRefreshed returns true, if the access token can be refreshed using the
+// refresh token, else false. The expiry is taken from the `exp` claim of the
+// refresh token.
 func (s *Session) CanBeRefreshed() bool {
-	now := jwt.Now()
-	refreshTokenExpired := s.Created.Add(time.Second * time.Duration(s.Token.RefreshExpiresIn)).After(now.Time)
-	return !refreshTokenExpired
+	expiresAt, err := s.RefreshTokenExpiry()
+	if err != nil {
+		return false
+	}
+	// offline tokens don't expire, unless a max lifespan is set for offline
+	// sessions
+	if expiresAt.IsZero() {
+		return true
+	}
+	return time.Now().Before(expiresAt)
 }
 
-// IsValid returns true, if the session object is indeed valid
+// AccessTokenExpiry returns the expiry time of the access token as stated in
+// its `exp` claim.
+func (s *Session) AccessTokenExpiry() (time.Time, error) {
+	return tokenExpiry(s.Token.AccessToken)
+}
+
+// RefreshTokenExpiry returns the expiry time of the refresh token as stated in
+// its `exp` claim. A zero time is returned, if the token doesn't expire.
+func (s *Session) RefreshTokenExpiry() (time.Time, error) {
+	return tokenExpiry(s.Token.RefreshToken)
+}
+
+// AccessTokenClaims decodes the claims of the access token without verifying
+// its signature.
+func (s *Session) AccessTokenClaims() (*AccessTokenClaims, error) {
+	claims := &AccessTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(s.Token.AccessToken, claims); err != nil {
+		return nil, errors.Wrap(err, "cannot decode access token")
+	}
+	return claims, nil
+}
+
+// IDTokenExpiry returns the expiry time of the ID token as stated in its `exp`
+// claim.
+func (s *Session) IDTokenExpiry() (time.Time, error) {
+	if s.Token.IDToken == "" {
+		return time.Time{}, errors.New("session has no ID token")
+	}
+	return tokenExpiry(s.Token.IDToken)
+}
+
+// tokenExpiry decodes the `exp` claim of a JWT without verifying its
+// signature.
+func tokenExpiry(token string) (time.Time, error) {
+	claims := jwt.StandardClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return time.Time{}, errors.Wrap(err, "cannot decode token")
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// IsValid returns true, if the session object is indeed valid. A session
+// without refresh token is valid only, if it was created by the client
+// credentials grant, which doesn't necessarily issue one, or if it was
+// exported without the refresh token on purpose. The creation time isn't
+// compared with the local clock, because sessions are transferred between
+// machines, whose clocks might differ. An explicit proxy must be a valid URL,
+// so that requests never bypass it.
 func (s *Session) IsValid() bool {
 	_, err := url.ParseRequestURI(s.URL)
 	if err != nil ||
@@ -110,20 +309,60 @@ func (s *Session) IsValid() bool {
 		s.Realm == "" ||
 		s.ClientID == "" ||
 		s.Created.Equal(time.Time{}) ||
-		s.Created.After(jwt.Now().Time) ||
 		s.Token.ExpiresIn <= 0 ||
-		s.Token.RefreshExpiresIn <= 0 ||
+		s.Token.RefreshExpiresIn < 0 ||
 		s.Token.AccessToken == "" ||
-		s.Token.RefreshToken == "" ||
+		(s.Token.RefreshToken == "" && s.GrantType != GrantClientCredentials && !s.AccessOnly) ||
 		s.Token.TokenType != "Bearer" {
 		return false
 	}
+	if s.Proxy != "" {
+		proxyURL, err := url.Parse(s.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return false
+		}
+	}
 	return true
 }
 
+// ResolveRealm returns the realm to be managed: The given realm, if not empty,
+// else the target realm of the session or the login realm.
+func (s *Session) ResolveRealm(realm string) string {
+	switch {
+	case realm != "":
+		return realm
+	case s.TargetRealm != "":
+		return s.TargetRealm
+	default:
+		return s.Realm
+	}
+}
+
+// Realm returns the realm, that issued the token, as stated by the issuer.
+func (c *AccessTokenClaims) Realm() string {
+	return path.Base(c.Issuer)
+}
+
+// HasRole returns true, if the role is granted as realm role or as client role
+// of the given client.
+func (c *AccessTokenClaims) HasRole(role, clientID string) bool {
+	for _, r := range c.RealmAccess.Roles {
+		if r == role {
+			return true
+		}
+	}
+	for _, r := range c.ResourceAccess[clientID].Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type sessionService struct {
 	repository SessionRepository
 	provider   SessionProvider
+	agent      SessionAgent
 }
 
 // NewSessionService initializes a `SessionService`.
@@ -131,86 +370,138 @@ func NewSessionService(repo SessionRepository, provider SessionProvider) Session
 	return &sessionService{repository: repo, provider: provider}
 }
 
-func (ss *sessionService) Load(name string) (*Session, error) {
-	if exists, _ := ss.repository.Exists(name); !exists {
-		return nil, errors.Errorf("session '%s': does not exist", name)
+// NewSessionServiceWithAgent initializes a `SessionService`, that loads
+// sessions from a session agent. The session repository is used instead, if
+// the agent is unavailable.
+func NewSessionServiceWithAgent(repo SessionRepository, provider SessionProvider, agent SessionAgent) SessionService {
+	return &sessionService{repository: repo, provider: provider, agent: agent}
+}
+
+func (ss *sessionService) List() ([]string, error) {
+	names, err := ss.repository.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sessions")
 	}
+	return names, nil
+}
 
-	// lock session repository for exclusive access
-	if err := ss.repository.Open(name); err != nil {
+func (ss *sessionService) Location(name string) string {
+	return ss.repository.Location(name)
+}
+
+func (ss *sessionService) Load(ctx context.Context, name string) (*Session, error) {
+	if ss.agent != nil {
+		session, err := ss.agent.Load(ctx, name)
+		if errors.Cause(err) != ErrAgentUnavailable {
+			return session, err
+		}
+	}
+
+	return ss.load(ctx, name)
+}
+
+func (ss *sessionService) LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*Session, error) {
+	if ss.agent != nil {
+		session, err := ss.agent.LoadRefresh(ctx, name, minValidity)
+		if errors.Cause(err) != ErrAgentUnavailable {
+			return session, err
+		}
+	}
+
+	session, err := ss.load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !session.IsExpired(minValidity) {
+		return session, nil
+	}
+
+	// lock session repository for exclusive access and read the session again,
+	// because another process might have refreshed it in the meantime. This
+	// way concurrent processes perform only a single refresh between them.
+	if err := ss.repository.Open(ctx, name, true); err != nil {
 		return nil, err
 	}
 	defer ss.repository.Close()
+	session, err = ss.read(name)
+	if err != nil {
+		return nil, err
+	}
 
-	// read the session information from the repository
-	session, err := ss.repository.Read()
+	// refresh the access token
+	refreshed, err := ss.Refresh(ctx, session, minValidity)
 	if err != nil {
-		return nil, errors.Wrapf(err, "session '%s': failed to retrieve from repository", name)
+		return nil, err
 	}
 
-	// check if the loaded session is valid
-	if !session.IsValid() {
-		return nil, errors.Errorf("session '%s': invalid. Login again to create a new session", name)
+	// write any changes to the session file
+	if refreshed {
+		if err := ss.repository.Write(session); err != nil {
+			return nil, errors.Wrapf(err, "session '%s': failed to write to repository", name)
+		}
 	}
 
 	return session, nil
 }
 
-func (ss *sessionService) LoadRefresh(name string, refreshBeforeExpiry bool) (*Session, error) {
+// load loads a session from the repository using shared access.
+func (ss *sessionService) load(ctx context.Context, name string) (*Session, error) {
+	if err := ValidateSessionName(name); err != nil {
+		return nil, err
+	}
 	if exists, _ := ss.repository.Exists(name); !exists {
-		return nil, errors.Errorf("session '%s': does not exist", name)
+		return nil, NewError(KindNotFound, "session '%s': does not exist", name)
 	}
 
-	// lock session repository for exclusive access
-	if err := ss.repository.Open(name); err != nil {
+	// lock session repository for shared access
+	if err := ss.repository.Open(ctx, name, false); err != nil {
 		return nil, err
 	}
 	defer ss.repository.Close()
 
+	return ss.read(name)
+}
+
+// read reads a session from the opened repository and checks its validity.
+func (ss *sessionService) read(name string) (*Session, error) {
 	// read the session information from the repository
 	session, err := ss.repository.Read()
+	if errors.Cause(err) == ErrSessionCorrupted {
+		return nil, errors.Wrapf(err, "session '%s': corrupted. Restore the last good state using 'sessions restore %s' or login again", name, name)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "session '%s': failed to retrieve from repository", name)
 	}
 
 	// check if the loaded session is valid
 	if !session.IsValid() {
-		return nil, errors.Errorf("session '%s': invalid. Login again to create a new session", name)
-	}
-
-	// refresh the access token
-	refreshed, err := ss.Refresh(session, refreshBeforeExpiry)
-	if err != nil {
-		return nil, err
-	}
-
-	// write any changes to the session file
-	if refreshed {
-		if err := ss.repository.Write(session); err != nil {
-			return nil, errors.Wrapf(err, "session '%s': failed to write to repository", name)
-		}
+		return nil, NewError(KindUnauthorized, "session '%s': invalid. Login again to create a new session", name)
 	}
 
 	return session, nil
 }
 
-func (ss *sessionService) CreateWithUsernamePassword(name, url, realm, clientID, user, password string, skipVerify bool) (*Session, error) {
+func (ss *sessionService) CreateWithUsernamePassword(ctx context.Context, opts SessionOptions, user, password string) (*Session, error) {
 	createFunc := func() (*Session, error) {
-		return ss.provider.CreateWithUsernamePassword(name, url, realm, clientID, user, password, skipVerify)
+		return ss.provider.CreateWithUsernamePassword(ctx, opts, user, password)
 	}
-	return ss.create(name, createFunc)
+	return ss.create(ctx, opts.Name, createFunc)
 }
 
-func (ss *sessionService) CreateWithClientSecret(name, url, realm, clientID, secret string, skipVerify bool) (*Session, error) {
+func (ss *sessionService) CreateWithClientSecret(ctx context.Context, opts SessionOptions, secret string) (*Session, error) {
 	createFunc := func() (*Session, error) {
-		return ss.provider.CreateWithClientSecret(name, url, realm, clientID, secret, skipVerify)
+		return ss.provider.CreateWithClientSecret(ctx, opts, secret)
 	}
-	return ss.create(name, createFunc)
+	return ss.create(ctx, opts.Name, createFunc)
 }
 
-func (ss *sessionService) create(name string, createFunc func() (*Session, error)) (*Session, error) {
+func (ss *sessionService) create(ctx context.Context, name string, createFunc func() (*Session, error)) (*Session, error) {
+	if err := ValidateSessionName(name); err != nil {
+		return nil, err
+	}
+
 	// lock session repository for exclusive access
-	if err := ss.repository.Open(name); err != nil {
+	if err := ss.repository.Open(ctx, name, true); err != nil {
 		return nil, errors.Wrapf(err, "session '%s': failed to open repository", name)
 	}
 	defer ss.repository.Close()
@@ -225,19 +516,23 @@ func (ss *sessionService) create(name string, createFunc func() (*Session, error
 	if err := ss.repository.Write(session); err != nil {
 		return nil, errors.Wrapf(err, "session '%s': failed to write session information", name)
 	}
+	ss.forget(ctx, name)
 
 	return session, nil
 }
 
-func (ss *sessionService) Refresh(session *Session, beforeExpiry bool) (bool, error) {
-	if !session.IsExpired(beforeExpiry) {
+func (ss *sessionService) Refresh(ctx context.Context, session *Session, minValidity time.Duration) (bool, error) {
+	if !session.IsExpired(minValidity) {
 		return false, nil
 	}
+	if session.AccessOnly {
+		return false, NewError(KindUnauthorized, "session '%s': was exported without refresh token and cannot be refreshed. Export the session again or login to create a new session", session.Name)
+	}
 	if !session.CanBeRefreshed() {
-		return false, errors.Errorf("session '%s': is expired. Login again to create a new session", session.Name)
+		return false, NewError(KindUnauthorized, "session '%s': is expired. Login again to create a new session", session.Name)
 	}
 
-	refreshed, err := ss.provider.Refresh(session)
+	refreshed, err := ss.provider.Refresh(ctx, session)
 	if err != nil {
 		return false, errors.Wrapf(err, "session '%s': failed to refresh", session.Name)
 	}
@@ -245,15 +540,134 @@ func (ss *sessionService) Refresh(session *Session, beforeExpiry bool) (bool, er
 	return refreshed, nil
 }
 
-func (ss *sessionService) End(session *Session, force bool) error {
-	if !session.IsValid() || !session.CanBeRefreshed() {
-		return ss.repository.Remove(session.Name)
+func (ss *sessionService) End(ctx context.Context, session *Session, force, revoke bool) (bool, error) {
+	defer ss.forget(ctx, session.Name)
+
+	if !session.IsValid() {
+		return false, ss.repository.Remove(session.Name)
+	}
+
+	if revoke {
+		err := ss.provider.Revoke(ctx, session)
+		if err == nil {
+			// revoking the refresh token ends the session, a logout using the
+			// revoked token would fail
+			return true, ss.repository.Remove(session.Name)
+		}
+		if !force {
+			return false, errors.Wrapf(err, "session '%s': failed to revoke tokens", session.Name)
+		}
+	}
+
+	if !session.CanBeRefreshed() {
+		return false, ss.repository.Remove(session.Name)
 	}
 
-	err := ss.provider.End(session)
+	err := ss.provider.End(ctx, session)
 	if err != nil && !force {
-		return errors.Errorf("session '%s': failed to logout: %v", session.Name, err)
+		return false, errors.Wrapf(err, "session '%s': failed to logout", session.Name)
+	}
+
+	return false, ss.repository.Remove(session.Name)
+}
+
+func (ss *sessionService) Remove(ctx context.Context, name string) error {
+	if err := ValidateSessionName(name); err != nil {
+		return err
+	}
+	defer ss.forget(ctx, name)
+
+	if err := ss.repository.Remove(name); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to remove", name)
+	}
+	return nil
+}
+
+func (ss *sessionService) Verify(ctx context.Context, session *Session) error {
+	if err := ss.provider.Verify(ctx, session); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to verify access token", session.Name)
+	}
+	return nil
+}
+
+// forget removes a session from the memory of the agent, so that the agent
+// doesn't hand out an outdated session.
+func (ss *sessionService) forget(ctx context.Context, name string) {
+	if ss.agent != nil {
+		ss.agent.Forget(ctx, name)
+	}
+}
+
+func (ss *sessionService) Restore(ctx context.Context, name string) error {
+	if err := ValidateSessionName(name); err != nil {
+		return err
+	}
+	if err := ss.repository.Open(ctx, name, true); err != nil {
+		return err
+	}
+	defer ss.repository.Close()
+
+	if err := ss.repository.Restore(); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to restore", name)
+	}
+	ss.forget(ctx, name)
+
+	_, err := ss.read(name)
+	return err
+}
+
+func (ss *sessionService) SetTargetRealm(ctx context.Context, name, realm string) error {
+	if err := ValidateSessionName(name); err != nil {
+		return err
+	}
+	if exists, _ := ss.repository.Exists(name); !exists {
+		return NewError(KindNotFound, "session '%s': does not exist", name)
+	}
+
+	// lock session repository for exclusive access
+	if err := ss.repository.Open(ctx, name, true); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to open repository", name)
+	}
+	defer ss.repository.Close()
+
+	session, err := ss.read(name)
+	if err != nil {
+		return err
+	}
+	session.TargetRealm = realm
+	if err := ss.repository.Write(session); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to write session information", name)
+	}
+	ss.forget(ctx, name)
+
+	return nil
+}
+
+func (ss *sessionService) Import(ctx context.Context, session *Session, overwrite bool) error {
+	if err := ValidateSessionName(session.Name); err != nil {
+		return err
+	}
+	if !session.IsValid() {
+		return NewError(KindValidation, "session '%s': invalid", session.Name)
+	}
+	if session.IsExpired(0) && !session.CanBeRefreshed() {
+		return NewError(KindUnauthorized, "session '%s': is expired", session.Name)
+	}
+
+	if exists, _ := ss.repository.Exists(session.Name); exists && !overwrite {
+		return NewError(KindAlreadyExists, "session '%s': already exists", session.Name)
+	}
+
+	// lock session repository for exclusive access
+	if err := ss.repository.Open(ctx, session.Name, true); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to open repository", session.Name)
+	}
+	defer ss.repository.Close()
+
+	if err := ss.repository.Write(session); err != nil {
+		return errors.Wrapf(err, "session '%s': failed to write session information", session.Name)
 	}
+	ss.forget(ctx, session.Name)
 
-	return ss.repository.Remove(session.Name)
+	return nil
 }
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+// newValidSession returns a session created by a password login.
+func newValidSession() *Session {
+	session := &Session{
+		Name:     "test",
+		URL:      "https://sso.example.org/",
+		Realm:    "master",
+		ClientID: "admin-cli",
+		Created:  *jwt.Now(),
+	}
+	session.Token.AccessToken = "access"
+	session.Token.RefreshToken = "refresh"
+	session.Token.ExpiresIn = 300
+	session.Token.TokenType = "Bearer"
+	return session
+}
+
+func TestSessionIsValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *Session)
+		want   bool
+	}{
+		{"valid", func(s *Session) {}, true},
+		{"created in the future", func(s *Session) { s.Created = *jwt.At(time.Now().Add(5 * time.Minute)) }, true},
+		{"not created", func(s *Session) { s.Created = jwt.Time{} }, false},
+		{"without refresh token", func(s *Session) { s.Token.RefreshToken = "" }, false},
+		{"client credentials without refresh token", func(s *Session) {
+			s.Token.RefreshToken = ""
+			s.GrantType = GrantClientCredentials
+		}, true},
+		{"access-only", func(s *Session) {
+			s.Token.RefreshToken = ""
+			s.AccessOnly = true
+		}, true},
+		{"without access token", func(s *Session) { s.Token.AccessToken = "" }, false},
+		{"invalid URL", func(s *Session) { s.URL = "sso.example.org" }, false},
+		{"proxy", func(s *Session) { s.Proxy = "http://proxy.example.org:3128" }, true},
+		{"proxy without host", func(s *Session) { s.Proxy = "proxy.example.org" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := newValidSession()
+			tt.modify(session)
+			if got := session.IsValid(); got != tt.want {
+				t.Errorf("IsValid() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// dialTimeout is the maximum time to wait for a connection to the agent.
+const dialTimeout = 2 * time.Second
+
+// agentClient implements `core.SessionAgent`. It requests sessions from an
+// agent listening on a unix socket.
+type agentClient struct {
+	socketPath string
+}
+
+// NewClient initializes a client for the agent listening on the given socket.
+func NewClient(socketPath string) core.SessionAgent {
+	return &agentClient{socketPath: socketPath}
+}
+
+func (ac *agentClient) Load(ctx context.Context, name string) (*core.Session, error) {
+	return ac.request(ctx, request{Op: opLoad, Name: name})
+}
+
+func (ac *agentClient) LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*core.Session, error) {
+	return ac.request(ctx, request{Op: opLoadRefresh, Name: name, MinValidity: minValidity})
+}
+
+func (ac *agentClient) Forget(ctx context.Context, name string) error {
+	_, err := ac.request(ctx, request{Op: opForget, Name: name})
+	return err
+}
+
+// request sends a request to the agent and waits for the response, until the
+// context is done.
+func (ac *agentClient) request(ctx context.Context, req request) (*core.Session, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", ac.socketPath)
+	if err != nil {
+		return nil, errors.Wrapf(core.ErrAgentUnavailable, "cannot connect to '%s': %v", ac.socketPath, err)
+	}
+	defer conn.Close()
+
+	// abort the pending I/O, when the context is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrapf(core.ErrAgentUnavailable, "cannot send request: %v", err)
+	}
+	resp := response{}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrapf(core.ErrAgentUnavailable, "cannot receive response: %v", err)
+	}
+	if resp.Error != "" {
+		if resp.Kind == "" {
+			return nil, errors.New(resp.Error)
+		}
+		return nil, core.WrapError(resp.Kind, errors.New(resp.Error))
+	}
+
+	return resp.Session, nil
+}
@@ -0,0 +1,48 @@
+//go:build linux
+// +build linux
+
+package agent
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// CheckPlatform returns an error, if the agent cannot be run securely on the
+// current platform.
+func CheckPlatform() error {
+	return nil
+}
+
+// checkPeer ensures, that the peer of a unix socket connection is run by the
+// same user as the agent.
+func checkPeer(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return errors.New("not a unix socket connection")
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	err = rawConn.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return err
+	}
+	if credErr != nil {
+		return errors.Wrap(credErr, "cannot retrieve peer credentials")
+	}
+
+	if int(cred.Uid) != os.Getuid() {
+		return errors.Errorf("peer (pid %d) is run by uid %d", cred.Pid, cred.Uid)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package agent
+
+import (
+	"net"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// CheckPlatform returns an error, because the peer credentials of unix socket
+// connections cannot be retrieved on this platform. Without them any process
+// able to connect to the socket could obtain the sessions.
+func CheckPlatform() error {
+	return errors.Errorf("the agent is not supported on %s", runtime.GOOS)
+}
+
+// checkPeer rejects all connections, see CheckPlatform.
+func checkPeer(conn net.Conn) error {
+	return CheckPlatform()
+}
@@ -0,0 +1,49 @@
+// Package agent provides a session agent, that keeps sessions in memory and
+// serves them over a unix socket, and a client implementing
+// `core.SessionAgent`.
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// SocketEnvVar is the name of the environment variable, that holds the path of
+// the agent socket.
+const SocketEnvVar = "KEYCLI_AGENT_SOCK"
+
+const (
+	opLoad        = "load"
+	opLoadRefresh = "load-refresh"
+	opForget      = "forget"
+)
+
+// request is sent by the client to the agent. Each connection carries a single
+// request and response.
+type request struct {
+	Op          string        `json:"op"`
+	Name        string        `json:"name"`
+	MinValidity time.Duration `json:"min_validity,omitempty"`
+}
+
+// response is sent by the agent to the client. The kind of an error is sent
+// along with its message, so that the client can restore it.
+type response struct {
+	Session *core.Session  `json:"session,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Kind    core.ErrorKind `json:"kind,omitempty"`
+}
+
+// DefaultSocketPath returns the default path of the agent socket.
+func DefaultSocketPath() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join(os.TempDir(), "keycli-"+strconv.Itoa(os.Getuid()))
+		return filepath.Join(runtimeDir, "agent.sock")
+	}
+	return filepath.Join(runtimeDir, "keycli", "agent.sock")
+}
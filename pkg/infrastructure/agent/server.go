@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	// refreshInterval is the interval, in which the held sessions are checked
+	// for expiry.
+	refreshInterval = 30 * time.Second
+	// refreshAhead is the time span before the expiry of an access token, in
+	// which the token is refreshed proactively.
+	refreshAhead = 2 * time.Minute
+	// requestTimeout is the maximum time for receiving, processing and answering
+	// the request of a client.
+	requestTimeout = 30 * time.Second
+)
+
+// Server keeps sessions in memory and serves them to clients connecting over
+// a unix socket.
+type Server struct {
+	// newService creates a session service for a single operation, because a
+	// session repository holds the lock of a single session only.
+	newService func() core.SessionService
+	// mu guards the maps only, it is never held while loading or refreshing
+	// a session.
+	mu       sync.Mutex
+	sessions map[string]*core.Session
+	// locks serialize the operations on a single session, so that concurrent
+	// requests for an expired session cause a single refresh only.
+	locks map[string]*sync.Mutex
+}
+
+// NewServer initializes a new `Server`, that loads sessions using the session
+// services created by the given function. The session services must not use
+// an agent themselves.
+func NewServer(newService func() core.SessionService) *Server {
+	return &Server{
+		newService: newService,
+		sessions:   make(map[string]*core.Session),
+		locks:      make(map[string]*sync.Mutex),
+	}
+}
+
+// lockSession locks the session with the given name and returns a function to
+// unlock it again.
+func (s *Server) lockSession(name string) func() {
+	s.mu.Lock()
+	lock, ok := s.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[name] = lock
+	}
+	s.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// cached returns the session held in memory.
+func (s *Server) cached(name string) (*core.Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[name]
+	return session, ok
+}
+
+// store holds the session in memory or removes it, if it is nil.
+func (s *Server) store(name string, session *core.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session == nil {
+		delete(s.sessions, name)
+		return
+	}
+	s.sessions[name] = session
+}
+
+// Serve accepts connections on the listener until the context is done. The
+// listener is closed on return.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+// RefreshLoop refreshes the held sessions proactively, until the context is
+// done. Sessions, that cannot be refreshed, are removed from memory.
+func (s *Server) RefreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshAll(ctx)
+		}
+	}
+}
+
+func (s *Server) refreshAll(ctx context.Context) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.sessions))
+	for name := range s.sessions {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	for _, name := range names {
+		s.refresh(ctx, name)
+	}
+}
+
+// refresh refreshes a single held session, if it is about to expire.
+func (s *Server) refresh(ctx context.Context, name string) {
+	unlock := s.lockSession(name)
+	defer unlock()
+
+	session, ok := s.cached(name)
+	if !ok || !session.IsExpired(refreshAhead) {
+		return
+	}
+	refreshed, err := s.newService().LoadRefresh(ctx, name, refreshAhead)
+	if err != nil {
+		log.Printf("dropping session '%s': %v", name, err)
+		s.store(name, nil)
+		return
+	}
+	s.store(name, refreshed)
+}
+
+// handle serves a single request of a client.
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if err := checkPeer(conn); err != nil {
+		log.Printf("rejected connection: %v", err)
+		return
+	}
+
+	req := request{}
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Printf("cannot decode request: %v", err)
+		return
+	}
+
+	resp := response{}
+	session, err := s.process(ctx, req)
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Kind = core.KindOf(err)
+	} else {
+		resp.Session = session
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Printf("cannot send response: %v", err)
+	}
+}
+
+func (s *Server) process(ctx context.Context, req request) (*core.Session, error) {
+	unlock := s.lockSession(req.Name)
+	defer unlock()
+
+	switch req.Op {
+	case opLoad:
+		if session, ok := s.cached(req.Name); ok {
+			return session, nil
+		}
+		session, err := s.newService().Load(ctx, req.Name)
+		if err != nil {
+			return nil, err
+		}
+		s.store(req.Name, session)
+		return session, nil
+
+	case opLoadRefresh:
+		if session, ok := s.cached(req.Name); ok && !session.IsExpired(req.MinValidity) {
+			return session, nil
+		}
+		session, err := s.newService().LoadRefresh(ctx, req.Name, req.MinValidity)
+		if err != nil {
+			return nil, err
+		}
+		s.store(req.Name, session)
+		return session, nil
+
+	case opForget:
+		s.store(req.Name, nil)
+		return nil, nil
+	}
+
+	return nil, errors.Errorf("unknown operation '%s'", req.Op)
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// fakeSessionService remembers the session it was used for, like a session
+// repository holding the lock of a single session. Methods not overridden
+// panic.
+type fakeSessionService struct {
+	core.SessionService
+	name string
+}
+
+func (f *fakeSessionService) Load(ctx context.Context, name string) (*core.Session, error) {
+	if f.name != "" && f.name != name {
+		panic("session service used for sessions '" + f.name + "' and '" + name + "'")
+	}
+	f.name = name
+	return &core.Session{Name: name}, nil
+}
+
+func TestServerUsesServicePerOperation(t *testing.T) {
+	var mu sync.Mutex
+	services := 0
+	server := NewServer(func() core.SessionService {
+		mu.Lock()
+		defer mu.Unlock()
+		services++
+		return &fakeSessionService{}
+	})
+
+	names := []string{"a", "b", "c", "d"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			session, err := server.process(context.Background(), request{Op: opLoad, Name: name})
+			if err != nil || session.Name != name {
+				t.Errorf("process(%s) = %v, %v", name, session, err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if services != len(names) {
+		t.Errorf("created %d session services, want %d", services, len(names))
+	}
+	// cached sessions are served without a service
+	if _, err := server.process(context.Background(), request{Op: opLoad, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if services != len(names) {
+		t.Errorf("created %d session services, want %d", services, len(names))
+	}
+}
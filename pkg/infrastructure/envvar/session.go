@@ -0,0 +1,197 @@
+// Package envvar provides an implementation of the repository interfaces,
+// that takes sessions from environment variables instead of files. It is
+// intended for CI environments, where no session data should be kept on disk.
+package envvar
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SessionDataEnvVar holds a base64 encoded session as produced by the
+	// sessions export command.
+	SessionDataEnvVar = "KEYCLI_SESSION_DATA"
+	// ClientIDEnvVar holds the client ID used for a client credentials login.
+	ClientIDEnvVar = "KEYCLI_CLIENT_ID"
+	// ClientSecretEnvVar holds the client secret used for a client credentials
+	// login.
+	ClientSecretEnvVar = "KEYCLI_CLIENT_SECRET"
+	// URLEnvVar holds the base URL of Keycloak used for a client credentials
+	// login.
+	URLEnvVar = "KEYCLI_URL"
+	// RealmEnvVar holds the realm used for a client credentials login. Defaults
+	// to 'master'.
+	RealmEnvVar = "KEYCLI_REALM"
+	// SkipVerifyEnvVar disables the TLS certificate verification for a client
+	// credentials login, if set to 'true'.
+	SkipVerifyEnvVar = "KEYCLI_SKIP_VERIFY"
+	// TargetRealmEnvVar holds the realm managed by default, if it differs from
+	// the login realm.
+	TargetRealmEnvVar = "KEYCLI_TARGET_REALM"
+)
+
+// envSessionRepository implements `core.SessionRepository`. The session is
+// read from the environment and kept in memory only. Every session name
+// refers to the single session given by the environment.
+type envSessionRepository struct {
+	provider core.SessionProvider
+	session  *core.Session
+	name     string
+	// ctx is the context of the current access, which is used for a client
+	// credentials login
+	ctx context.Context
+}
+
+// IsConfigured returns true, if the environment provides a session.
+func IsConfigured() bool {
+	return os.Getenv(SessionDataEnvVar) != "" || os.Getenv(ClientIDEnvVar) != ""
+}
+
+// NewEnvSessionRepository initializes an `envSessionRepository`. The provider
+// is used for logging in with client credentials.
+func NewEnvSessionRepository(provider core.SessionProvider) core.SessionRepository {
+	return &envSessionRepository{provider: provider}
+}
+
+// Exists returns true, if the environment provides a session.
+func (es *envSessionRepository) Exists(name string) (bool, error) {
+	return IsConfigured(), nil
+}
+
+// List returns the name of the session provided by the environment.
+func (es *envSessionRepository) List() ([]string, error) {
+	if !IsConfigured() {
+		return []string{}, nil
+	}
+	return []string{"env"}, nil
+}
+
+// Open selects the session to be read. No locking is required, because the
+// session is held in memory of the current process only.
+func (es *envSessionRepository) Open(ctx context.Context, name string, exclusive bool) error {
+	es.name = name
+	es.ctx = ctx
+	return nil
+}
+
+// Close ends the current access.
+func (es *envSessionRepository) Close() error {
+	es.ctx = nil
+	return nil
+}
+
+// Read returns the session held in memory. On first use the session is decoded
+// from the environment or created by a client credentials login. When using
+// client credentials, an expired session, that cannot be refreshed, is
+// replaced by a new login.
+func (es *envSessionRepository) Read() (*core.Session, error) {
+	if es.session != nil && !(es.canLogin() && es.session.IsExpired(core.DefaultMinValidity) && !es.session.CanBeRefreshed()) {
+		return es.copySession(), nil
+	}
+
+	var err error
+	if rawData := os.Getenv(SessionDataEnvVar); rawData != "" {
+		es.session, err = decodeSessionData(rawData)
+	} else {
+		es.session, err = es.login()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if targetRealm := strings.TrimSpace(os.Getenv(TargetRealmEnvVar)); targetRealm != "" {
+		es.session.TargetRealm = targetRealm
+	}
+	return es.copySession(), nil
+}
+
+// Write keeps the session in memory.
+func (es *envSessionRepository) Write(session *core.Session) error {
+	if session == nil {
+		return nil
+	}
+	sessionCopy := *session
+	es.session = &sessionCopy
+	return nil
+}
+
+// Restore isn't supported, because the session isn't persisted.
+func (es *envSessionRepository) Restore() error {
+	return errors.New("sessions provided by the environment cannot be restored")
+}
+
+// Remove removes the session from memory.
+func (es *envSessionRepository) Remove(name string) error {
+	es.session = nil
+	return nil
+}
+
+// Location describes the storage of the session, which is kept in memory
+// only.
+func (es *envSessionRepository) Location(name string) string {
+	return "the memory of the current process"
+}
+
+// copySession returns a copy of the session held in memory, so that changes
+// made by the caller require a write.
+func (es *envSessionRepository) copySession() *core.Session {
+	sessionCopy := *es.session
+	if es.name != "" {
+		sessionCopy.Name = es.name
+	}
+	return &sessionCopy
+}
+
+// canLogin returns true, if a login can be performed using client credentials
+// from the environment.
+func (es *envSessionRepository) canLogin() bool {
+	return os.Getenv(SessionDataEnvVar) == "" && os.Getenv(ClientIDEnvVar) != ""
+}
+
+// login creates a new session using the client credentials from the
+// environment.
+func (es *envSessionRepository) login() (*core.Session, error) {
+	url := strings.TrimSpace(os.Getenv(URLEnvVar))
+	if url == "" {
+		return nil, errors.Errorf("%s must be set for a client credentials login", URLEnvVar)
+	}
+	if !strings.HasSuffix(url, "/") {
+		url = url + "/"
+	}
+	realm := strings.TrimSpace(os.Getenv(RealmEnvVar))
+	if realm == "" {
+		realm = "master"
+	}
+
+	opts := core.SessionOptions{
+		Name:       es.name,
+		URL:        url,
+		Realm:      realm,
+		ClientID:   os.Getenv(ClientIDEnvVar),
+		SkipVerify: os.Getenv(SkipVerifyEnvVar) == "true",
+	}
+	ctx := es.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	session, err := es.provider.CreateWithClientSecret(ctx, opts, os.Getenv(ClientSecretEnvVar))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to login with client credentials from the environment")
+	}
+	return session, nil
+}
+
+// decodeSessionData decodes a session in the format of KEYCLI_SESSION_DATA,
+// which is the format produced by the sessions export command.
+func decodeSessionData(data string) (*core.Session, error) {
+	session, err := jsonfile.ImportSession(data, "")
+	if err != nil {
+		return nil, errors.Wrapf(core.ErrSessionCorrupted, "cannot decode %s: %v", SessionDataEnvVar, err)
+	}
+	return session, nil
+}
@@ -0,0 +1,118 @@
+package jsonfile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptedExportPrefix marks an exported session, that is encrypted with a
+// passphrase.
+const encryptedExportPrefix = "keycli-encrypted-v1:"
+
+const (
+	saltSize = 16
+	keySize  = 32
+	// scrypt cost parameters as recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrPassphraseRequired is returned by `ImportSession`, if the exported session
+// is encrypted, but no passphrase was given.
+var ErrPassphraseRequired = errors.New("the exported session is encrypted, a passphrase is required")
+
+// ExportSession encodes a session for the transfer to another machine or for
+// the use in KEYCLI_SESSION_DATA. The result is base64 encoded. If a
+// passphrase is given, the session is encrypted using AES-256-GCM with a key
+// derived from the passphrase by scrypt.
+func ExportSession(session *core.Session, passphrase string) (string, error) {
+	rawData, err := encodeSession(session)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return base64.StdEncoding.EncodeToString(rawData), nil
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// salt | nonce | ciphertext
+	payload := append(salt, nonce...)
+	payload = aead.Seal(payload, nonce, rawData, nil)
+	return encryptedExportPrefix + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+// ImportSession decodes a session exported by `ExportSession`. The passphrase
+// is only required, if the session was encrypted.
+func ImportSession(data, passphrase string) (*core.Session, error) {
+	data = strings.TrimSpace(data)
+	if !IsEncryptedExport(data) {
+		rawData, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode exported session")
+		}
+		return DecodeSession(rawData)
+	}
+
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(data, encryptedExportPrefix))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode exported session")
+	}
+	if len(payload) < saltSize {
+		return nil, errors.New("cannot decode exported session: payload too short")
+	}
+	salt, payload := payload[:saltSize], payload[saltSize:]
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < aead.NonceSize() {
+		return nil, errors.New("cannot decode exported session: payload too short")
+	}
+	nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	rawData, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt exported session: wrong passphrase or corrupted data")
+	}
+	return DecodeSession(rawData)
+}
+
+// IsEncryptedExport returns true, if an exported session is encrypted.
+func IsEncryptedExport(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), encryptedExportPrefix)
+}
+
+// newAEAD derives a key from the passphrase and initializes an AES-GCM cipher.
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot derive key from passphrase")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
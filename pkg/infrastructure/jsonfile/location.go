@@ -0,0 +1,134 @@
+package jsonfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// HomeEnvVar is the name of the environment variable, that overrides the
+// directory in which keycli stores its data.
+const HomeEnvVar = "KEYCLI_HOME"
+
+// projectDirName is the name of the project-local keycli directory.
+const projectDirName = ".keycli"
+
+// legacyMigration makes sure the sessions of the legacy location are moved
+// only once per process.
+var legacyMigration sync.Once
+
+// SessionsDir returns the directory in which the session files are stored. In
+// order of precedence the directory is located in:
+//
+//  1. the directory given by the environment variable KEYCLI_HOME
+//  2. a project-local .keycli directory, found in the current working
+//     directory or one of its parents
+//  3. the user's state directory (e.g.: ~/.local/state/keycli)
+func SessionsDir() string {
+	if home := os.Getenv(HomeEnvVar); home != "" {
+		return filepath.Join(home, "sessions")
+	}
+	if projectDir := ProjectDir(); projectDir != "" {
+		return filepath.Join(projectDir, "sessions")
+	}
+	dir := filepath.Join(userStateDir(), "keycli", "sessions")
+	legacyMigration.Do(func() { migrateLegacySessions(dir) })
+	return dir
+}
+
+// PathFromName creates the file path for a given session name.
+func PathFromName(name string) string {
+	return filepath.Join(SessionsDir(), name+".json")
+}
+
+// CacheDir returns the directory for data, that can be fetched again at any
+// time. It is located inside the sessions directory, so that it is covered by
+// the same .gitignore file.
+func CacheDir() string {
+	return filepath.Join(SessionsDir(), ".cache")
+}
+
+// ProjectDir returns the project-local .keycli directory, which is searched
+// for in the current working directory and its parents up to, but excluding,
+// the user's home directory. Returns an empty string, if none is found.
+func ProjectDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	userHome, _ := os.UserHomeDir()
+	for {
+		if dir == userHome {
+			return ""
+		}
+		candidate := filepath.Join(dir, projectDirName)
+		if fileInfo, err := os.Stat(candidate); err == nil && fileInfo.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// userStateDir returns the directory for persistent user specific application
+// state. It follows the XDG base directory specification on Unix systems.
+func userStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		if dir, err := os.UserConfigDir(); err == nil {
+			return dir
+		}
+	default:
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "state")
+		}
+	}
+	// if no suitable dir can be determined use a temp dir
+	return os.TempDir()
+}
+
+// createSessionsDir creates the directory for the session files. A
+// .gitignore file is placed in the directory, so that the sessions of a
+// project-local directory aren't committed accidentally.
+func createSessionsDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		return ioutil.WriteFile(gitignore, []byte("*\n"), 0600)
+	}
+	return nil
+}
+
+// migrateLegacySessions moves session files from the location used by earlier
+// versions of keycli (the user's cache directory) to the given directory.
+// Sessions already present in the new location are left untouched.
+func migrateLegacySessions(dir string) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+	legacyDir := filepath.Join(cacheDir, "keycli", "tokens")
+	paths, err := filepath.Glob(filepath.Join(legacyDir, "*.json"))
+	if err != nil || len(paths) == 0 {
+		return
+	}
+	if err := createSessionsDir(dir); err != nil {
+		return
+	}
+	for _, path := range paths {
+		newPath := filepath.Join(dir, filepath.Base(path))
+		if _, err := os.Stat(newPath); os.IsNotExist(err) {
+			os.Rename(path, newPath)
+		}
+	}
+}
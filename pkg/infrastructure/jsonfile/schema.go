@@ -0,0 +1,145 @@
+package jsonfile
+
+import (
+	"encoding/json"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// schemaVersion is the version of the session file format written by this
+// version of keycli. It must be increased with every incompatible change of
+// `core.Session`, along with a migration for the older format.
+const schemaVersion = 4
+
+// sessionFile is the stored format of a session.
+type sessionFile struct {
+	Version int             `json:"version"`
+	Session json.RawMessage `json:"session"`
+}
+
+// migration upgrades the raw content of a session file by one version.
+type migration func(rawData []byte) ([]byte, error)
+
+// migrations maps a schema version to the migration, that upgrades a session
+// file of that version to the next one.
+var migrations = map[int]migration{
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+	3: migrateV3ToV4,
+}
+
+// migrateV1ToV2 wraps the session, which was stored unversioned, into the
+// versioned session file format.
+func migrateV1ToV2(rawData []byte) ([]byte, error) {
+	return json.Marshal(sessionFile{Version: 2, Session: rawData})
+}
+
+// migrateV2ToV3 adds the server information. Sessions of older versions were
+// created against servers using the `/auth` base path only.
+func migrateV2ToV3(rawData []byte) ([]byte, error) {
+	file := sessionFile{}
+	if err := json.Unmarshal(rawData, &file); err != nil {
+		return nil, err
+	}
+	session := map[string]json.RawMessage{}
+	if err := json.Unmarshal(file.Session, &session); err != nil {
+		return nil, err
+	}
+	server, err := json.Marshal(core.ServerInfo{BasePath: "/auth"})
+	if err != nil {
+		return nil, err
+	}
+	session["server"] = server
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sessionFile{Version: 3, Session: rawSession})
+}
+
+// migrateV3ToV4 adds the target realm. Sessions of older versions manage the
+// realm used for logging in, which is what an empty target realm stands for.
+// Files of version 4 are refused by older versions of keycli, which would drop
+// the target realm when writing the session back.
+func migrateV3ToV4(rawData []byte) ([]byte, error) {
+	file := sessionFile{}
+	if err := json.Unmarshal(rawData, &file); err != nil {
+		return nil, err
+	}
+	session := map[string]json.RawMessage{}
+	if err := json.Unmarshal(file.Session, &session); err != nil {
+		return nil, err
+	}
+	if _, ok := session["target_realm"]; !ok {
+		session["target_realm"] = json.RawMessage(`""`)
+	}
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sessionFile{Version: 4, Session: rawSession})
+}
+
+// decodeSession decodes the content of a session file. Files of an older
+// schema version are migrated first. Returns true, if the file was migrated.
+func decodeSession(rawData []byte) (*core.Session, bool, error) {
+	// session files without a version predate the versioned format
+	probe := struct {
+		Version *int `json:"version"`
+	}{}
+	if err := json.Unmarshal(rawData, &probe); err != nil {
+		return nil, false, err
+	}
+	version := 1
+	if probe.Version != nil {
+		version = *probe.Version
+	}
+	if version > schemaVersion {
+		return nil, false, errors.Errorf("schema version %d is not supported, the file was written by a newer version of keycli", version)
+	}
+
+	// run migration chain
+	migrated := version < schemaVersion
+	for ; version < schemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, false, errors.Errorf("no migration from schema version %d available", version)
+		}
+		var err error
+		if rawData, err = migrate(rawData); err != nil {
+			return nil, false, errors.Wrapf(err, "cannot migrate from schema version %d", version)
+		}
+	}
+
+	file := sessionFile{}
+	if err := json.Unmarshal(rawData, &file); err != nil {
+		return nil, false, err
+	}
+	session := &core.Session{}
+	if err := json.Unmarshal(file.Session, session); err != nil {
+		return nil, false, err
+	}
+	return session, migrated, nil
+}
+
+// encodeSession encodes a session in the current session file format.
+func encodeSession(session *core.Session) ([]byte, error) {
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sessionFile{Version: schemaVersion, Session: rawSession})
+}
+
+// EncodeSession encodes a session in the current session file format.
+func EncodeSession(session *core.Session) ([]byte, error) {
+	return encodeSession(session)
+}
+
+// DecodeSession decodes a session encoded in any supported session file
+// format.
+func DecodeSession(rawData []byte) (*core.Session, error) {
+	session, _, err := decodeSession(rawData)
+	return session, err
+}
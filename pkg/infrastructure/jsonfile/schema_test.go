@@ -0,0 +1,115 @@
+package jsonfile
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// v1Session is a session file written before the format was versioned.
+const v1Session = `{
+	"name": "legacy",
+	"url": "https://sso.example.org/",
+	"realm": "master",
+	"client_id": "admin-cli",
+	"token": {"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer"}
+}`
+
+// v3Session is a session file of version 3, which predates the target realm.
+const v3Session = `{
+	"version": 3,
+	"session": {
+		"name": "current",
+		"url": "https://sso.example.org/",
+		"realm": "foo",
+		"client_id": "admin-cli",
+		"token": {"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer"},
+		"server": {"base_path": "", "version": "21.1.0"}
+	}
+}`
+
+func TestDecodeSession(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantMigrated bool
+		want         core.Session
+	}{
+		{
+			name:         "unversioned",
+			data:         v1Session,
+			wantMigrated: true,
+			want:         core.Session{Name: "legacy", Realm: "master", Server: core.ServerInfo{BasePath: "/auth"}},
+		},
+		{
+			name:         "version 3",
+			data:         v3Session,
+			wantMigrated: true,
+			want:         core.Session{Name: "current", Realm: "foo", Server: core.ServerInfo{Version: "21.1.0"}},
+		},
+		{
+			name: "current version",
+			data: `{"version": 4, "session": {"name": "new", "realm": "master", "target_realm": "bar", "server": {"base_path": ""}}}`,
+			want: core.Session{Name: "new", Realm: "master", TargetRealm: "bar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, migrated, err := decodeSession([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if migrated != tt.wantMigrated {
+				t.Errorf("migrated = %t, want %t", migrated, tt.wantMigrated)
+			}
+			if session.Name != tt.want.Name || session.Realm != tt.want.Realm || session.TargetRealm != tt.want.TargetRealm || session.Server != tt.want.Server {
+				t.Errorf("decoded session = %+v, want %+v", session, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSessionMigratesTokens(t *testing.T) {
+	session, _, err := decodeSession([]byte(v1Session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token.AccessToken != "access" || session.Token.RefreshToken != "refresh" || session.URL != "https://sso.example.org/" {
+		t.Errorf("decoded session = %+v, want the fields of the unversioned file", session)
+	}
+}
+
+func TestDecodeSessionRefusesNewerVersion(t *testing.T) {
+	_, _, err := decodeSession([]byte(`{"version": 5, "session": {"name": "future"}}`))
+	if err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("decodeSession() = %v, want an error about a newer version", err)
+	}
+}
+
+func TestDecodeSessionRefusesMalformed(t *testing.T) {
+	for _, data := range []string{"", "{", `{"version": "4"}`, `{"version": 4, "session": []}`} {
+		if _, _, err := decodeSession([]byte(data)); err == nil {
+			t.Errorf("decodeSession(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestEncodeSession(t *testing.T) {
+	data, err := encodeSession(&core.Session{Name: "test", TargetRealm: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := sessionFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != schemaVersion {
+		t.Errorf("version = %d, want %d", file.Version, schemaVersion)
+	}
+	session, migrated, err := decodeSession(data)
+	if err != nil || migrated || session.Name != "test" || session.TargetRealm != "foo" {
+		t.Errorf("decodeSession() = %+v, %t, %v, want the encoded session", session, migrated, err)
+	}
+}
@@ -2,22 +2,30 @@
 package jsonfile
 
 import (
-	"encoding/json"
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aisbergg/keycli/pkg/core"
 	"github.com/pkg/errors"
 	"github.com/rogpeppe/go-internal/lockedfile"
 )
 
+// lockTimeout is the maximum time to wait for the lock of a session file.
+const lockTimeout = 30 * time.Second
+
 // jsonfileSessionRepository implements `core.SessionRepository`. It loads and
-// stores session information in a json file. The file is locked for exclusive
-// access.
+// stores session information in a json file. Access to the session file is
+// guarded by a lock file, which is locked for shared access when reading and
+// for exclusive access when writing. Writes are atomic and the last good
+// session is kept in a backup file.
 type jsonfileSessionRepository struct {
-	lFile *lockedfile.File
-	path  string
+	lockFile  *lockedfile.File
+	path      string
+	exclusive bool
 }
 
 // NewJSONFileSessionRepository initializes a `jsonfileSessionRepository`.
@@ -32,89 +40,168 @@ func (js *jsonfileSessionRepository) Exists(name string) (bool, error) {
 	return exists, err
 }
 
-// Open opens the session file for exclusive access.
-func (js *jsonfileSessionRepository) Open(name string) error {
+// Open locks the session file for shared or exclusive access. Gives up, if the
+// lock cannot be acquired within the lock timeout or the context is done.
+func (js *jsonfileSessionRepository) Open(ctx context.Context, name string, exclusive bool) error {
 	path := PathFromName(name)
 
 	// create parent dir
-	err := os.MkdirAll(filepath.Dir(path), 0700)
+	err := createSessionsDir(filepath.Dir(path))
 	if err != nil {
 		return errors.Errorf("cannot create directory for session file '%s': %v", path, err)
 	}
 
-	// create and open locked session file
-	lFile, err := lockedfile.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	// create and open the lock file. A file opened read-only is locked for
+	// shared access.
+	flag := os.O_RDONLY | os.O_CREATE
+	if exclusive {
+		flag = os.O_RDWR | os.O_CREATE
+	}
+	lockFile, err := openLocked(ctx, lockPath(path), flag, lockTimeout)
 	if err != nil {
-		return errors.Errorf("cannot open session file '%s': %v", path, err)
+		return errors.Errorf("cannot lock session file '%s': %v", path, err)
 	}
-	js.lFile = lFile
-	js.path = name
+	js.lockFile = lockFile
+	js.path = path
+	js.exclusive = exclusive
 
 	return nil
 }
 
-// Close closes the session file, so that other instances can access it.
+// Close unlocks the session file, so that other instances can access it.
 func (js *jsonfileSessionRepository) Close() error {
-	if js.lFile == nil {
+	if js.lockFile == nil {
 		return nil
 	}
 
-	err := js.lFile.Close()
+	err := js.lockFile.Close()
+	js.lockFile = nil
 	if err != nil {
-		return errors.Errorf("cannot close session file '%s': %v", js.path, err)
+		return errors.Errorf("cannot unlock session file '%s': %v", js.path, err)
 	}
 
 	return nil
 }
 
-// Read reads the content of the session file. Returns `nil` if file doesn't exist.
+// Read reads the content of the session file. Files of an older schema version
+// are migrated and, if opened for exclusive access, written back. Returns an
+// error wrapping `core.ErrSessionCorrupted`, if the file cannot be decoded.
 func (js *jsonfileSessionRepository) Read() (*core.Session, error) {
-	if js.lFile == nil {
+	if js.lockFile == nil {
 		panic("session file must be opened before use")
 	}
 
 	// read file contents
-	rawData, err := ioutil.ReadAll(js.lFile)
+	rawData, err := ioutil.ReadFile(js.path)
 	if err != nil {
 		return nil, errors.Errorf("cannot read from session file '%s': %v", js.path, err)
 	}
 
 	// decode json content
-	session := &core.Session{}
-	err = json.Unmarshal(rawData, session)
+	session, migrated, err := decodeSession(rawData)
 	if err != nil {
-		return nil, errors.Errorf("cannot decode session file '%s': %v", js.path, err)
+		return nil, errors.Wrapf(core.ErrSessionCorrupted, "cannot decode session file '%s': %v", js.path, err)
+	}
+	if migrated && js.exclusive {
+		if err := js.Write(session); err != nil {
+			return nil, err
+		}
 	}
 	return session, nil
 }
 
-// Write writes session information to a file.
+// Write writes session information to a file. The data is written to a
+// temporary file first, which then replaces the session file. The replaced
+// session is kept as backup, if it is intact.
 func (js *jsonfileSessionRepository) Write(s *core.Session) error {
-	if js.lFile == nil {
+	if js.lockFile == nil {
 		panic("session file must be opened before use")
 	}
 
+	if !js.exclusive {
+		return errors.Errorf("cannot write to session file '%s': not opened for exclusive access", js.path)
+	}
+
 	if s == nil {
 		return nil
 	}
 
 	// json encode data
-	jsonData, err := json.Marshal(s)
+	jsonData, err := encodeSession(s)
 	if err != nil {
 		return err
 	}
 
-	// write to file
-	js.lFile.Truncate(0)
-	_, err = js.lFile.Write(jsonData)
+	// keep the current session as backup, unless it is corrupted
+	if currentData, err := ioutil.ReadFile(js.path); err == nil {
+		if _, _, err := decodeSession(currentData); err == nil {
+			if err := writeFileAtomic(backupPath(js.path), currentData); err != nil {
+				return errors.Errorf("cannot write backup of session file '%s': %v", js.path, err)
+			}
+		}
+	}
+
+	// replace session file
+	if err := writeFileAtomic(js.path, jsonData); err != nil {
+		return errors.Errorf("cannot write to session file '%s': %v", js.path, err)
+	}
+
+	return nil
+}
+
+// Restore replaces the session file with the backup of the last good session.
+func (js *jsonfileSessionRepository) Restore() error {
+	if js.lockFile == nil {
+		panic("session file must be opened before use")
+	}
+
+	if !js.exclusive {
+		return errors.Errorf("cannot restore session file '%s': not opened for exclusive access", js.path)
+	}
+
+	rawData, err := ioutil.ReadFile(backupPath(js.path))
 	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("no backup of session file '%s' available", js.path)
+		}
+		return errors.Errorf("cannot read backup of session file '%s': %v", js.path, err)
+	}
+	if _, _, err := decodeSession(rawData); err != nil {
+		return errors.Errorf("backup of session file '%s' is corrupted as well: %v", js.path, err)
+	}
+
+	if err := writeFileAtomic(js.path, rawData); err != nil {
 		return errors.Errorf("cannot write to session file '%s': %v", js.path, err)
 	}
 
 	return nil
 }
 
-// Remove removes the stored file. Has no effect, if the file doesn't exist.
+// List returns the names of all stored sessions.
+func (js *jsonfileSessionRepository) List() ([]string, error) {
+	dir := SessionsDir()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, errors.Errorf("cannot list session files in '%s': %v", dir, err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && filepath.Ext(entry.Name()) == ".json" {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	return names, nil
+}
+
+// Remove removes the stored file together with its backup. Has no effect, if
+// the file doesn't exist. The files are removed while holding the exclusive
+// lock, so that no other process reads a half removed session. The lock file
+// is kept: Processes waiting for the lock would acquire it on a removed file,
+// while others lock a newly created one.
 func (js *jsonfileSessionRepository) Remove(name string) error {
 	path := PathFromName(name)
 	exists, err := checkFile(path)
@@ -124,23 +211,114 @@ func (js *jsonfileSessionRepository) Remove(name string) error {
 	if err != nil {
 		return errors.Errorf("cannot remove session file '%s': %v", path, err)
 	}
+
+	// the lock is held already, if the repository is opened for the session
+	if js.lockFile == nil || js.path != path {
+		lockFile, err := openLocked(context.Background(), lockPath(path), os.O_RDWR|os.O_CREATE, lockTimeout)
+		if err != nil {
+			return errors.Errorf("cannot lock session file '%s': %v", path, err)
+		}
+		defer lockFile.Close()
+	}
+
 	err = os.Remove(path)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return errors.Errorf("cannot remove session file '%s': %v", path, err)
 	}
-
+	err = os.Remove(backupPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Errorf("cannot remove backup of session file '%s': %v", path, err)
+	}
 	return nil
 }
 
-// PathFromName creates the file path for a given session name.
-func PathFromName(name string) string {
-	cacheDir, err := os.UserCacheDir()
-	// if chache dir cannot be determined use a temp dir
+// Location returns the path of the session file.
+func (js *jsonfileSessionRepository) Location(name string) string {
+	return PathFromName(name)
+}
+
+// lockPath returns the path of the lock file guarding a session file.
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// backupPath returns the path of the backup of a session file.
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory, syncs
+// it to disk and then renames it to the given path. This way the file either
+// contains the old or the new data, even if the write is interrupted.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmpFile, err := ioutil.TempFile(dir, filepath.Base(path)+".*.tmp")
 	if err != nil {
-		cacheDir = os.TempDir()
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	// sync the directory to persist the rename, not supported on all platforms
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+
+	return nil
+}
+
+// openLocked opens a locked file. Waits at most `timeout` for the lock to be
+// acquired or until the context is done.
+func openLocked(ctx context.Context, path string, flag int, timeout time.Duration) (*lockedfile.File, error) {
+	type result struct {
+		lFile *lockedfile.File
+		err   error
+	}
+	done := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		lFile, err := lockedfile.OpenFile(path, flag, 0600)
+		select {
+		case done <- result{lFile, err}:
+		case <-abandoned:
+			// nobody waits for the file anymore
+			if err == nil {
+				lFile.Close()
+			}
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.lFile, res.err
+	case <-timer.C:
+		close(abandoned)
+		return nil, errors.Errorf("timed out after %s waiting for the lock. Another keycli process seems to hold it", timeout)
+	case <-ctx.Done():
+		close(abandoned)
+		return nil, errors.Wrap(ctx.Err(), "gave up waiting for the lock")
 	}
-	path := filepath.Join(cacheDir, "keycli", "tokens", name+".json")
-	return path
 }
 
 // checkFile checks whether a given path exists and if it is a file.
@@ -0,0 +1,168 @@
+package jsonfile
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// openTestRepository opens the repository of a session for exclusive access in
+// a temporary sessions directory.
+func openTestRepository(t *testing.T, name string) core.SessionRepository {
+	home, ok := os.LookupEnv(HomeEnvVar)
+	os.Setenv(HomeEnvVar, t.TempDir())
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(HomeEnvVar, home)
+		} else {
+			os.Unsetenv(HomeEnvVar)
+		}
+	})
+
+	repo := NewJSONFileSessionRepository()
+	if err := repo.Open(context.Background(), name, true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func writeTestSession(t *testing.T, repo core.SessionRepository, realm string) {
+	if err := repo.Write(&core.Session{Name: "test", Realm: realm}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRealm returns the realm of the session stored in the given file.
+func readRealm(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _, err := decodeSession(data)
+	if err != nil {
+		t.Fatalf("cannot decode '%s': %v", path, err)
+	}
+	return session.Realm
+}
+
+func corruptFile(t *testing.T, path string) {
+	if err := ioutil.WriteFile(path, []byte(`{"version": 4, "sess`), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteKeepsBackup(t *testing.T) {
+	repo := openTestRepository(t, "test")
+	path := PathFromName("test")
+
+	writeTestSession(t, repo, "first")
+	if _, err := os.Stat(backupPath(path)); !os.IsNotExist(err) {
+		t.Errorf("backup written for a new session file: %v", err)
+	}
+	writeTestSession(t, repo, "second")
+	if realm := readRealm(t, path); realm != "second" {
+		t.Errorf("session realm = %s, want second", realm)
+	}
+	if realm := readRealm(t, backupPath(path)); realm != "first" {
+		t.Errorf("backup realm = %s, want first", realm)
+	}
+}
+
+func TestWriteKeepsBackupOfCorruptedFile(t *testing.T) {
+	repo := openTestRepository(t, "test")
+	path := PathFromName("test")
+
+	writeTestSession(t, repo, "first")
+	writeTestSession(t, repo, "second")
+	corruptFile(t, path)
+	writeTestSession(t, repo, "third")
+
+	if realm := readRealm(t, path); realm != "third" {
+		t.Errorf("session realm = %s, want third", realm)
+	}
+	if realm := readRealm(t, backupPath(path)); realm != "first" {
+		t.Errorf("backup realm = %s, want the last intact session first", realm)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	repo := openTestRepository(t, "test")
+	path := PathFromName("test")
+
+	writeTestSession(t, repo, "first")
+	writeTestSession(t, repo, "second")
+	corruptFile(t, path)
+
+	if _, err := repo.Read(); errors.Cause(err) != core.ErrSessionCorrupted {
+		t.Fatalf("Read() = %v, want %v", err, core.ErrSessionCorrupted)
+	}
+	if err := repo.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	session, err := repo.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Realm != "first" {
+		t.Errorf("restored realm = %s, want first", session.Realm)
+	}
+}
+
+func TestRestoreRefusesCorruptedBackup(t *testing.T) {
+	repo := openTestRepository(t, "test")
+	path := PathFromName("test")
+
+	if err := repo.Restore(); err == nil {
+		t.Error("Restore() without backup succeeded, want an error")
+	}
+	writeTestSession(t, repo, "first")
+	writeTestSession(t, repo, "second")
+	corruptFile(t, path)
+	corruptFile(t, backupPath(path))
+	if err := repo.Restore(); err == nil {
+		t.Error("Restore() with corrupted backup succeeded, want an error")
+	}
+}
+
+func TestReadMigratesFile(t *testing.T) {
+	repo := openTestRepository(t, "legacy")
+	path := PathFromName("legacy")
+	if err := ioutil.WriteFile(path, []byte(v1Session), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Read(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, migrated, err := decodeSession(data); err != nil || migrated {
+		t.Errorf("session file wasn't written back in the current format: %v", err)
+	}
+}
+
+func TestRemoveKeepsLockFile(t *testing.T) {
+	repo := openTestRepository(t, "test")
+	path := PathFromName("test")
+	writeTestSession(t, repo, "first")
+	writeTestSession(t, repo, "second")
+
+	if err := repo.Remove("test"); err != nil {
+		t.Fatal(err)
+	}
+	for _, removed := range []string{path, backupPath(path)} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("'%s' wasn't removed: %v", removed, err)
+		}
+	}
+	if _, err := os.Stat(lockPath(path)); err != nil {
+		t.Errorf("lock file was removed: %v", err)
+	}
+}
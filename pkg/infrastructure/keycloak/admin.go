@@ -0,0 +1,460 @@
+package keycloak
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// keycloakAdminProvider implements `core.AdminProvider`
+type keycloakAdminProvider struct {
+	http HTTPOptions
+}
+
+// NewKeycloakAdminProvider initializes a new `keycloakAdminProvider`, that
+// sends its requests using the given options.
+func NewKeycloakAdminProvider(opts HTTPOptions) core.AdminProvider {
+	return &keycloakAdminProvider{http: opts}
+}
+
+// adminClient creates a gocloak client for the server of a session.
+func (ap *keycloakAdminProvider) adminClient(session *core.Session) gocloak.GoCloak {
+	return *createGoclaokClient(sessionConnection(session, ap.http))
+}
+
+// -----------------------------------------------------------------------------
+// Users
+// -----------------------------------------------------------------------------
+
+func (ap *keycloakAdminProvider) ListUsers(ctx context.Context, session *core.Session, realm string, opts core.ListOptions) ([]core.User, error) {
+	params := gocloak.GetUsersParams{First: intP(opts.First), Max: intP(opts.Max)}
+	if opts.Exact {
+		// the exact flag of Keycloak applies to the username, not to the
+		// search string
+		params.Username = stringP(opts.Search)
+		params.Exact = gocloak.BoolP(true)
+	} else {
+		params.Search = stringP(opts.Search)
+	}
+	users, err := ap.adminClient(session).GetUsers(ctx, session.Token.AccessToken, realm, params)
+	if err != nil {
+		return nil, errors.Wrap(classifyError(err), "failed to list users")
+	}
+	return toUsers(users), nil
+}
+
+func (ap *keycloakAdminProvider) GetUser(ctx context.Context, session *core.Session, realm, id string) (*core.User, error) {
+	user, err := ap.adminClient(session).GetUserByID(ctx, session.Token.AccessToken, realm, id)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to get user '%s'", id)
+	}
+	result := toUser(user)
+	return &result, nil
+}
+
+func (ap *keycloakAdminProvider) CreateUser(ctx context.Context, session *core.Session, realm string, user core.User) (string, error) {
+	id, err := ap.adminClient(session).CreateUser(ctx, session.Token.AccessToken, realm, fromUser(user))
+	if err != nil {
+		return "", errors.Wrapf(classifyError(err), "failed to create user '%s'", user.Username)
+	}
+	return id, nil
+}
+
+func (ap *keycloakAdminProvider) UpdateUser(ctx context.Context, session *core.Session, realm string, user core.User) error {
+	if err := ap.adminClient(session).UpdateUser(ctx, session.Token.AccessToken, realm, fromUser(user)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to update user '%s'", user.ID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) DeleteUser(ctx context.Context, session *core.Session, realm, id string) error {
+	if err := ap.adminClient(session).DeleteUser(ctx, session.Token.AccessToken, realm, id); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to delete user '%s'", id)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) SetPassword(ctx context.Context, session *core.Session, realm, id, password string, temporary bool) error {
+	if err := ap.adminClient(session).SetPassword(ctx, session.Token.AccessToken, id, realm, password, temporary); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to set password of user '%s'", id)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) ListUserGroups(ctx context.Context, session *core.Session, realm, id string) ([]core.Group, error) {
+	groups, err := ap.adminClient(session).GetUserGroups(ctx, session.Token.AccessToken, realm, id, gocloak.GetGroupsParams{})
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to list groups of user '%s'", id)
+	}
+	return toGroups(groups), nil
+}
+
+func (ap *keycloakAdminProvider) AddUserToGroup(ctx context.Context, session *core.Session, realm, userID, groupID string) error {
+	if err := ap.adminClient(session).AddUserToGroup(ctx, session.Token.AccessToken, realm, userID, groupID); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to add user '%s' to group '%s'", userID, groupID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) RemoveUserFromGroup(ctx context.Context, session *core.Session, realm, userID, groupID string) error {
+	if err := ap.adminClient(session).DeleteUserFromGroup(ctx, session.Token.AccessToken, realm, userID, groupID); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to remove user '%s' from group '%s'", userID, groupID)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Groups
+// -----------------------------------------------------------------------------
+
+func (ap *keycloakAdminProvider) ListGroups(ctx context.Context, session *core.Session, realm string, opts core.ListOptions) ([]core.Group, error) {
+	params := gocloak.GetGroupsParams{First: intP(opts.First), Max: intP(opts.Max), Search: stringP(opts.Search)}
+	groups, err := ap.adminClient(session).GetGroups(ctx, session.Token.AccessToken, realm, params)
+	if err != nil {
+		return nil, errors.Wrap(classifyError(err), "failed to list groups")
+	}
+	return toGroups(groups), nil
+}
+
+func (ap *keycloakAdminProvider) GetGroup(ctx context.Context, session *core.Session, realm, id string) (*core.Group, error) {
+	group, err := ap.adminClient(session).GetGroup(ctx, session.Token.AccessToken, realm, id)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to get group '%s'", id)
+	}
+	result := toGroup(*group)
+	return &result, nil
+}
+
+func (ap *keycloakAdminProvider) CreateGroup(ctx context.Context, session *core.Session, realm string, group core.Group) (string, error) {
+	id, err := ap.adminClient(session).CreateGroup(ctx, session.Token.AccessToken, realm, fromGroup(group))
+	if err != nil {
+		return "", errors.Wrapf(classifyError(err), "failed to create group '%s'", group.Name)
+	}
+	return id, nil
+}
+
+func (ap *keycloakAdminProvider) UpdateGroup(ctx context.Context, session *core.Session, realm string, group core.Group) error {
+	if err := ap.adminClient(session).UpdateGroup(ctx, session.Token.AccessToken, realm, fromGroup(group)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to update group '%s'", group.ID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) DeleteGroup(ctx context.Context, session *core.Session, realm, id string) error {
+	if err := ap.adminClient(session).DeleteGroup(ctx, session.Token.AccessToken, realm, id); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to delete group '%s'", id)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) ListGroupMembers(ctx context.Context, session *core.Session, realm, id string, opts core.ListOptions) ([]core.User, error) {
+	params := gocloak.GetGroupsParams{First: intP(opts.First), Max: intP(opts.Max)}
+	users, err := ap.adminClient(session).GetGroupMembers(ctx, session.Token.AccessToken, realm, id, params)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to list members of group '%s'", id)
+	}
+	return toUsers(users), nil
+}
+
+// -----------------------------------------------------------------------------
+// Roles
+// -----------------------------------------------------------------------------
+
+func (ap *keycloakAdminProvider) ListRealmRoles(ctx context.Context, session *core.Session, realm string) ([]core.Role, error) {
+	roles, err := ap.adminClient(session).GetRealmRoles(ctx, session.Token.AccessToken, realm)
+	if err != nil {
+		return nil, errors.Wrap(classifyError(err), "failed to list realm roles")
+	}
+	return toRoles(roles), nil
+}
+
+func (ap *keycloakAdminProvider) GetRealmRole(ctx context.Context, session *core.Session, realm, name string) (*core.Role, error) {
+	role, err := ap.adminClient(session).GetRealmRole(ctx, session.Token.AccessToken, realm, name)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to get realm role '%s'", name)
+	}
+	result := toRole(*role)
+	return &result, nil
+}
+
+func (ap *keycloakAdminProvider) CreateRealmRole(ctx context.Context, session *core.Session, realm string, role core.Role) error {
+	if _, err := ap.adminClient(session).CreateRealmRole(ctx, session.Token.AccessToken, realm, fromRole(role)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to create realm role '%s'", role.Name)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) DeleteRealmRole(ctx context.Context, session *core.Session, realm, name string) error {
+	if err := ap.adminClient(session).DeleteRealmRole(ctx, session.Token.AccessToken, realm, name); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to delete realm role '%s'", name)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) ListUserRealmRoles(ctx context.Context, session *core.Session, realm, userID string) ([]core.Role, error) {
+	roles, err := ap.adminClient(session).GetRealmRolesByUserID(ctx, session.Token.AccessToken, realm, userID)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to list realm roles of user '%s'", userID)
+	}
+	return toRoles(roles), nil
+}
+
+func (ap *keycloakAdminProvider) GrantRealmRoles(ctx context.Context, session *core.Session, realm, userID string, roles []core.Role) error {
+	if err := ap.adminClient(session).AddRealmRoleToUser(ctx, session.Token.AccessToken, realm, userID, fromRoles(roles)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to grant realm roles to user '%s'", userID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) RevokeRealmRoles(ctx context.Context, session *core.Session, realm, userID string, roles []core.Role) error {
+	if err := ap.adminClient(session).DeleteRealmRoleFromUser(ctx, session.Token.AccessToken, realm, userID, fromRoles(roles)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to revoke realm roles from user '%s'", userID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) ListClientRoles(ctx context.Context, session *core.Session, realm, id string) ([]core.Role, error) {
+	roles, err := ap.adminClient(session).GetClientRoles(ctx, session.Token.AccessToken, realm, id)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to list roles of client '%s'", id)
+	}
+	return toRoles(roles), nil
+}
+
+// -----------------------------------------------------------------------------
+// Clients
+// -----------------------------------------------------------------------------
+
+func (ap *keycloakAdminProvider) ListClients(ctx context.Context, session *core.Session, realm string, opts core.ListOptions) ([]core.Client, error) {
+	params := gocloak.GetClientsParams{First: intP(opts.First), Max: intP(opts.Max), ClientID: stringP(opts.Search)}
+	clients, err := ap.adminClient(session).GetClients(ctx, session.Token.AccessToken, realm, params)
+	if err != nil {
+		return nil, errors.Wrap(classifyError(err), "failed to list clients")
+	}
+	result := make([]core.Client, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, toClient(*client))
+	}
+	return result, nil
+}
+
+func (ap *keycloakAdminProvider) GetClient(ctx context.Context, session *core.Session, realm, id string) (*core.Client, error) {
+	client, err := ap.adminClient(session).GetClient(ctx, session.Token.AccessToken, realm, id)
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "failed to get client '%s'", id)
+	}
+	result := toClient(*client)
+	return &result, nil
+}
+
+func (ap *keycloakAdminProvider) CreateClient(ctx context.Context, session *core.Session, realm string, client core.Client) (string, error) {
+	id, err := ap.adminClient(session).CreateClient(ctx, session.Token.AccessToken, realm, fromClient(client))
+	if err != nil {
+		return "", errors.Wrapf(classifyError(err), "failed to create client '%s'", client.ClientID)
+	}
+	return id, nil
+}
+
+func (ap *keycloakAdminProvider) UpdateClient(ctx context.Context, session *core.Session, realm string, client core.Client) error {
+	if err := ap.adminClient(session).UpdateClient(ctx, session.Token.AccessToken, realm, fromClient(client)); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to update client '%s'", client.ID)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) DeleteClient(ctx context.Context, session *core.Session, realm, id string) error {
+	if err := ap.adminClient(session).DeleteClient(ctx, session.Token.AccessToken, realm, id); err != nil {
+		return errors.Wrapf(classifyError(err), "failed to delete client '%s'", id)
+	}
+	return nil
+}
+
+func (ap *keycloakAdminProvider) GetClientSecret(ctx context.Context, session *core.Session, realm, id string) (string, error) {
+	credential, err := ap.adminClient(session).GetClientSecret(ctx, session.Token.AccessToken, realm, id)
+	if err != nil {
+		return "", errors.Wrapf(classifyError(err), "failed to get secret of client '%s'", id)
+	}
+	return gocloak.PString(credential.Value), nil
+}
+
+// -----------------------------------------------------------------------------
+// Conversion
+// -----------------------------------------------------------------------------
+
+func toUsers(users []*gocloak.User) []core.User {
+	result := make([]core.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, toUser(user))
+	}
+	return result
+}
+
+func toUser(user *gocloak.User) core.User {
+	result := core.User{
+		ID:              gocloak.PString(user.ID),
+		Username:        gocloak.PString(user.Username),
+		Email:           gocloak.PString(user.Email),
+		EmailVerified:   boolValue(user.EmailVerified),
+		FirstName:       gocloak.PString(user.FirstName),
+		LastName:        gocloak.PString(user.LastName),
+		Enabled:         boolValue(user.Enabled),
+		RequiredActions: gocloak.PStringSlice(user.RequiredActions),
+	}
+	if user.CreatedTimestamp != nil {
+		result.Created = time.Unix(0, *user.CreatedTimestamp*int64(time.Millisecond))
+	}
+	if user.Attributes != nil {
+		result.Attributes = *user.Attributes
+	}
+	return result
+}
+
+func fromUser(user core.User) gocloak.User {
+	result := gocloak.User{
+		ID:            stringP(user.ID),
+		Username:      gocloak.StringP(user.Username),
+		Email:         gocloak.StringP(user.Email),
+		EmailVerified: gocloak.BoolP(user.EmailVerified),
+		FirstName:     gocloak.StringP(user.FirstName),
+		LastName:      gocloak.StringP(user.LastName),
+		Enabled:       gocloak.BoolP(user.Enabled),
+	}
+	if user.RequiredActions != nil {
+		result.RequiredActions = &user.RequiredActions
+	}
+	if user.Attributes != nil {
+		result.Attributes = &user.Attributes
+	}
+	return result
+}
+
+func toGroups(groups []*gocloak.Group) []core.Group {
+	result := make([]core.Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, toGroup(*group))
+	}
+	return result
+}
+
+func toGroup(group gocloak.Group) core.Group {
+	result := core.Group{
+		ID:   gocloak.PString(group.ID),
+		Name: gocloak.PString(group.Name),
+		Path: gocloak.PString(group.Path),
+	}
+	if group.SubGroups != nil {
+		for _, subGroup := range *group.SubGroups {
+			result.SubGroups = append(result.SubGroups, toGroup(subGroup))
+		}
+	}
+	if group.Attributes != nil {
+		result.Attributes = *group.Attributes
+	}
+	return result
+}
+
+func fromGroup(group core.Group) gocloak.Group {
+	result := gocloak.Group{
+		ID:   stringP(group.ID),
+		Name: gocloak.StringP(group.Name),
+	}
+	if group.Attributes != nil {
+		result.Attributes = &group.Attributes
+	}
+	return result
+}
+
+func toRoles(roles []*gocloak.Role) []core.Role {
+	result := make([]core.Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, toRole(*role))
+	}
+	return result
+}
+
+func toRole(role gocloak.Role) core.Role {
+	return core.Role{
+		ID:          gocloak.PString(role.ID),
+		Name:        gocloak.PString(role.Name),
+		Description: gocloak.PString(role.Description),
+		Composite:   boolValue(role.Composite),
+		ClientRole:  boolValue(role.ClientRole),
+		ContainerID: gocloak.PString(role.ContainerID),
+	}
+}
+
+func fromRoles(roles []core.Role) []gocloak.Role {
+	result := make([]gocloak.Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, fromRole(role))
+	}
+	return result
+}
+
+func fromRole(role core.Role) gocloak.Role {
+	return gocloak.Role{
+		ID:          stringP(role.ID),
+		Name:        gocloak.StringP(role.Name),
+		Description: stringP(role.Description),
+	}
+}
+
+func toClient(client gocloak.Client) core.Client {
+	return core.Client{
+		ID:                     gocloak.PString(client.ID),
+		ClientID:               gocloak.PString(client.ClientID),
+		Name:                   gocloak.PString(client.Name),
+		Description:            gocloak.PString(client.Description),
+		Protocol:               gocloak.PString(client.Protocol),
+		Enabled:                boolValue(client.Enabled),
+		PublicClient:           boolValue(client.PublicClient),
+		ServiceAccountsEnabled: boolValue(client.ServiceAccountsEnabled),
+		RootURL:                gocloak.PString(client.RootURL),
+		RedirectURIs:           gocloak.PStringSlice(client.RedirectURIs),
+	}
+}
+
+func fromClient(client core.Client) gocloak.Client {
+	result := gocloak.Client{
+		ID:                     stringP(client.ID),
+		ClientID:               gocloak.StringP(client.ClientID),
+		Name:                   stringP(client.Name),
+		Description:            stringP(client.Description),
+		Protocol:               stringP(client.Protocol),
+		Enabled:                gocloak.BoolP(client.Enabled),
+		PublicClient:           gocloak.BoolP(client.PublicClient),
+		ServiceAccountsEnabled: gocloak.BoolP(client.ServiceAccountsEnabled),
+		RootURL:                stringP(client.RootURL),
+	}
+	if client.RedirectURIs != nil {
+		result.RedirectURIs = &client.RedirectURIs
+	}
+	return result
+}
+
+// stringP returns a pointer to the string or nil, if the string is empty, so
+// that empty values are omitted from requests.
+func stringP(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// intP returns a pointer to the integer or nil, if the integer is zero, so
+// that zero values are omitted from requests.
+func intP(value int) *int {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+// boolValue returns the value of a boolean pointer or false, if it is nil.
+// Unlike `gocloak.PBool`, it doesn't panic on nil.
+func boolValue(value *bool) bool {
+	return value != nil && *value
+}
@@ -0,0 +1,143 @@
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// APIRequest describes a raw request against the admin REST API of Keycloak.
+type APIRequest struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// Path is the path of the endpoint relative to the admin REST API root
+	// (e.g.: realms/master/users).
+	Path string
+	// Query holds the query parameters of the request.
+	Query url.Values
+	// Body is the JSON encoded request body.
+	Body []byte
+}
+
+// APIResponse is the response of a raw request against the admin REST API.
+type APIResponse struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// IsError returns true, if the response has an HTTP error status code.
+func (r *APIResponse) IsError() bool {
+	return r.StatusCode > 399
+}
+
+// Kind returns the error kind matching the status code of the response.
+func (r *APIResponse) Kind() core.ErrorKind {
+	if !r.IsError() {
+		return core.KindUnknown
+	}
+	return kindFromStatus(r.StatusCode, string(r.Body))
+}
+
+// Do sends a raw request to the admin REST API using the access token of the
+// client's session. HTTP error responses aren't treated as errors.
+func (c *client) Do(ctx context.Context, req APIRequest) (*APIResponse, error) {
+
+	restyRequest := (*c.gocloakClient).RestyClient().R().
+		SetContext(ctx).
+		SetAuthToken(c.session.Token.AccessToken).
+		SetHeader("Accept", "application/json").
+		SetQueryParamsFromValues(req.Query)
+	if req.Body != nil {
+		restyRequest.SetHeader("Content-Type", "application/json").SetBody(req.Body)
+	}
+
+	method := strings.ToUpper(req.Method)
+	resp, err := restyRequest.Execute(method, adminURL(c.session.URL, c.session.Server.BasePath, req.Path))
+	if err != nil {
+		return nil, errors.Wrapf(classifyError(err), "%s request failed", method)
+	}
+
+	return &APIResponse{
+		StatusCode: resp.StatusCode(),
+		Status:     resp.Status(),
+		Header:     resp.Header(),
+		Body:       resp.Body(),
+	}, nil
+}
+
+// maxPages is the maximum number of pages fetched by `DoPaginated`.
+const maxPages = 10000
+
+// DoPaginated sends a raw GET request to a list endpoint of the admin REST API
+// and follows the pagination using the `first` and `max` query parameters. The
+// JSON arrays of all pages are merged into a single one. The first HTTP error
+// response is returned as is. If the context is done, the error states how many
+// items were fetched so far. Endpoints, that ignore the query parameters,
+// return the complete list on every page; the pagination stops, when a page
+// starts with the same item as the previous one.
+func (c *client) DoPaginated(ctx context.Context, req APIRequest, pageSize int) (*APIResponse, error) {
+	if !strings.EqualFold(req.Method, http.MethodGet) {
+		return nil, errors.New("pagination is only supported for GET requests")
+	}
+
+	query := url.Values{}
+	for k, v := range req.Query {
+		query[k] = v
+	}
+	query.Set("max", strconv.Itoa(pageSize))
+
+	items := []json.RawMessage{}
+	var resp *APIResponse
+	var previousFirstItem json.RawMessage
+	for pages, first := 0, 0; ; pages, first = pages+1, first+pageSize {
+		if pages == maxPages {
+			return nil, errors.Errorf("cannot paginate, stopped after %d pages", maxPages)
+		}
+		query.Set("first", strconv.Itoa(first))
+		pageRequest := req
+		pageRequest.Query = query
+
+		var err error
+		resp, err = c.Do(ctx, pageRequest)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.Wrapf(ctx.Err(), "interrupted after fetching %d items", len(items))
+			}
+			return nil, err
+		}
+		if resp.IsError() {
+			return resp, nil
+		}
+
+		page := []json.RawMessage{}
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return nil, errors.Wrap(err, "cannot paginate, response is not a JSON array")
+		}
+		if len(page) > 0 && previousFirstItem != nil && bytes.Equal(page[0], previousFirstItem) {
+			break
+		}
+		if len(page) > 0 {
+			previousFirstItem = page[0]
+		}
+		items = append(items, page...)
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = body
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// newListServer serves a list of items. With `paginate` set, the `first` and
+// `max` query parameters are applied, else the complete list is returned.
+func newListServer(t *testing.T, total int, paginate bool) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		items := []string{}
+		for i := 0; i < total; i++ {
+			items = append(items, fmt.Sprintf("item-%d", i))
+		}
+		if paginate {
+			first, _ := strconv.Atoi(req.URL.Query().Get("first"))
+			max, _ := strconv.Atoi(req.URL.Query().Get("max"))
+			if first > len(items) {
+				first = len(items)
+			}
+			if first+max < len(items) {
+				items = items[first : first+max]
+			} else {
+				items = items[first:]
+			}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDoPaginated(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		paginate     bool
+		wantRequests int
+	}{
+		{"paginated", 250, true, 3},
+		{"paginated full pages", 200, true, 3},
+		{"empty", 0, true, 1},
+		{"pagination ignored", 150, false, 2},
+		{"pagination ignored, single page", 50, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newListServer(t, tt.total, tt.paginate)
+			c := NewClient(core.Session{URL: server.URL}, HTTPOptions{})
+
+			resp, err := c.DoPaginated(context.Background(), APIRequest{Method: http.MethodGet, Path: "realms"}, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items := []string{}
+			if err := json.Unmarshal(resp.Body, &items); err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tt.total {
+				t.Errorf("fetched %d items, want %d", len(items), tt.total)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
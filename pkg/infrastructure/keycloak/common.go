@@ -3,35 +3,106 @@
 package keycloak
 
 import (
-	"context"
 	"crypto/tls"
-	"time"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/Nerzal/gocloak/v8"
 	"github.com/aisbergg/keycli/pkg/core"
 )
 
-const timeout = 15 * time.Second
-
 type client struct {
 	gocloakClient *gocloak.GoCloak
 	session       *core.Session
 }
 
-func NewClient(session core.Session) *client {
-	gocloakClient := createGoclaokClient(session.URL, session.SkipVerify)
+// NewClient initializes a client, that accesses Keycloak using the given
+// session.
+func NewClient(session core.Session, opts HTTPOptions) *client {
+	gocloakClient := createGoclaokClient(sessionConnection(&session, opts))
 	return &client{gocloakClient: gocloakClient, session: &session}
 }
 
-func createGoclaokClient(url string, skipVerify bool) *gocloak.GoCloak {
-	gocloakClient := gocloak.NewClient(url)
-	if skipVerify {
-		restyClient := gocloakClient.RestyClient()
+// connection holds the settings for connecting to a server.
+type connection struct {
+	url        string
+	basePath   string
+	skipVerify bool
+	proxy      string
+	noProxy    bool
+	http       HTTPOptions
+}
+
+// sessionConnection returns the settings for connecting to the server of a
+// session.
+func sessionConnection(session *core.Session, opts HTTPOptions) connection {
+	return connection{
+		url:        session.URL,
+		basePath:   session.Server.BasePath,
+		skipVerify: session.SkipVerify,
+		proxy:      session.Proxy,
+		noProxy:    session.NoProxy,
+		http:       opts,
+	}
+}
+
+// createGoclaokClient creates a gocloak client for a server, that serves its
+// endpoints below the given base path (e.g.: `/auth`). Without an explicit
+// proxy, the proxy is taken from the environment (HTTPS_PROXY, NO_PROXY, ...).
+// An invalid proxy URL causes every request to fail.
+func createGoclaokClient(conn connection) *gocloak.GoCloak {
+	prefix := strings.Trim(conn.basePath, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	gocloakClient := gocloak.NewClient(conn.url,
+		gocloak.SetAuthRealms(prefix+"realms"),
+		gocloak.SetAuthAdminRealms(prefix+"admin/realms"),
+	)
+	restyClient := gocloakClient.RestyClient()
+	if conn.skipVerify {
 		restyClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
 	}
+	if transport, ok := restyClient.GetClient().Transport.(*http.Transport); ok {
+		switch {
+		case conn.noProxy:
+			transport.Proxy = nil
+		case conn.proxy != "":
+			proxyURL, err := url.Parse(conn.proxy)
+			if err != nil {
+				// never fall back to a direct connection or the proxy of the
+				// environment, but fail every request
+				err = core.NewError(core.KindValidation, "invalid proxy URL: %v", err)
+				transport.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+				break
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		default:
+			transport.Proxy = http.ProxyFromEnvironment
+		}
+	}
+	// the TLS and proxy settings must be applied before, because they require
+	// the original transport
+	restyClient.SetTransport(newRetryTransport(newTraceTransport(restyClient.GetClient().Transport, conn.http), conn.http))
 	return &gocloakClient
 }
 
-func createContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), timeout)
+// baseURL returns the URL of the server including the base path.
+func baseURL(url, basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return strings.TrimRight(url, "/")
+	}
+	return strings.TrimRight(url, "/") + "/" + basePath
+}
+
+// adminURL returns the URL of an endpoint of the admin REST API.
+func adminURL(url, basePath, path string) string {
+	return baseURL(url, basePath) + "/admin/" + strings.TrimLeft(path, "/")
+}
+
+// realmURL returns the URL of an endpoint of a realm.
+func realmURL(url, basePath, realm, path string) string {
+	return baseURL(url, basePath) + "/realms/" + realm + "/" + strings.TrimLeft(path, "/")
 }
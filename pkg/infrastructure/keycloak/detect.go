@@ -0,0 +1,67 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// basePathCandidates are the base paths, that are probed to find the endpoints
+// of a server. Quarkus based servers use no prefix by default, the legacy
+// WildFly based ones use `/auth`.
+var basePathCandidates = []string{"", "/auth"}
+
+// detectBasePath finds the base path of a server by probing the OpenID Connect
+// discovery endpoint of the realm below each candidate base path.
+func detectBasePath(ctx context.Context, conn connection, realm string) (string, error) {
+	url := conn.url
+	restyClient := (*createGoclaokClient(conn)).RestyClient()
+
+	var lastErr error
+	for _, basePath := range basePathCandidates {
+		resp, err := restyClient.R().
+			SetContext(ctx).
+			SetHeader("Accept", "application/json").
+			Get(realmURL(url, basePath, realm, ".well-known/openid-configuration"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode() != http.StatusOK {
+			continue
+		}
+		discovery := struct {
+			Issuer string `json:"issuer"`
+		}{}
+		if err := json.Unmarshal(resp.Body(), &discovery); err != nil || discovery.Issuer == "" {
+			continue
+		}
+		return basePath, nil
+	}
+
+	if lastErr != nil {
+		return "", errors.Wrapf(classifyError(lastErr), "cannot reach Keycloak at '%s'", url)
+	}
+	return "", core.NewError(core.KindNotFound, "cannot find realm '%s' at '%s', the URL doesn't seem to point to a Keycloak server", realm, url)
+}
+
+// detectVersion returns the version of a server as reported by the server info
+// endpoint of the admin REST API. An empty string is returned, if the version
+// cannot be retrieved (e.g.: because the user lacks the permission).
+func detectVersion(ctx context.Context, gocloakClient *gocloak.GoCloak, url, basePath, accessToken string) string {
+	serverInfo := gocloak.ServerInfoRepesentation{}
+	resp, err := (*gocloakClient).RestyClient().R().
+		SetContext(ctx).
+		SetAuthToken(accessToken).
+		SetHeader("Accept", "application/json").
+		SetResult(&serverInfo).
+		Get(adminURL(url, basePath, "serverinfo"))
+	if err != nil || resp.IsError() || serverInfo.SystemInfo == nil || serverInfo.SystemInfo.Version == nil {
+		return ""
+	}
+	return *serverInfo.SystemInfo.Version
+}
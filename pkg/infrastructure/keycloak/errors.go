@@ -0,0 +1,111 @@
+package keycloak
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// networkErrorMessages are parts of the messages of transport errors. gocloak
+// flattens transport errors into the message of an `APIError`, therefore they
+// can only be recognized by their message.
+var networkErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"no such host",
+	"network is unreachable",
+	"host is unreachable",
+	"i/o timeout",
+	"timed out",
+	context.DeadlineExceeded.Error(),
+	"TLS handshake",
+	"EOF",
+}
+
+// classifyError wraps an error returned by gocloak or resty with the matching
+// error kind. HTTP errors are classified by their status code, transport
+// errors and timeouts as network errors. Other errors (e.g.: malformed
+// responses) and canceled requests are left as they are.
+func classifyError(err error) error {
+	if err == nil || core.KindOf(err) != core.KindUnknown {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	var apiErr *gocloak.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != 0 {
+			return core.WrapError(kindFromStatus(apiErr.Code, apiErr.Message), err)
+		}
+		if strings.Contains(apiErr.Message, context.Canceled.Error()) {
+			return core.WrapError(core.KindInterrupted, err)
+		}
+		if isNetworkErrorMessage(apiErr.Message) {
+			return core.WrapError(core.KindNetwork, err)
+		}
+		return err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return core.WrapError(core.KindNetwork, err)
+	}
+	return err
+}
+
+// isNetworkErrorMessage returns true, if the message is the one of a transport
+// error.
+func isNetworkErrorMessage(message string) bool {
+	for _, part := range networkErrorMessages {
+		if strings.Contains(message, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// kindFromStatus maps an HTTP status code of Keycloak to an error kind. The
+// message of the response is used to tell apart some cases, that share a status
+// code:
+//
+//   - 400: Keycloak rejects expired or revoked refresh tokens with the error
+//     `invalid_grant`, which is classified as unauthorized.
+//   - 409: Keycloak answers requests creating a duplicate resource with
+//     messages like "User exists with same username" or "Role with name admin
+//     already exists", which are classified as already exists. Any other
+//     conflict is classified as such.
+//
+// Server errors and rate limits are classified as network errors, because they
+// are usually temporary.
+func kindFromStatus(status int, message string) core.ErrorKind {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		if strings.Contains(message, "invalid_grant") {
+			return core.KindUnauthorized
+		}
+		return core.KindValidation
+	case http.StatusUnauthorized:
+		return core.KindUnauthorized
+	case http.StatusForbidden:
+		return core.KindForbidden
+	case http.StatusNotFound:
+		return core.KindNotFound
+	case http.StatusConflict:
+		lowerMessage := strings.ToLower(message)
+		if strings.Contains(lowerMessage, "already exists") || strings.Contains(lowerMessage, "exists with same") {
+			return core.KindAlreadyExists
+		}
+		return core.KindConflict
+	case http.StatusTooManyRequests:
+		return core.KindNetwork
+	}
+	if status >= http.StatusInternalServerError && status < 600 {
+		return core.KindNetwork
+	}
+	return core.KindUnknown
+}
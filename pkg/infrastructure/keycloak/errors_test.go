@@ -0,0 +1,76 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+func TestKindFromStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		want    core.ErrorKind
+	}{
+		{400, "400 Bad Request: invalid_request", core.KindValidation},
+		{400, "400 Bad Request: invalid_grant: Token is not active", core.KindUnauthorized},
+		{422, "422 Unprocessable Entity", core.KindValidation},
+		{401, "401 Unauthorized", core.KindUnauthorized},
+		{403, "403 Forbidden", core.KindForbidden},
+		{404, "404 Not Found", core.KindNotFound},
+		{409, "409 Conflict: User exists with same username", core.KindAlreadyExists},
+		{409, "409 Conflict: Role with name admin already exists", core.KindAlreadyExists},
+		{409, "409 Conflict: Top level group named 'dev' already exists.", core.KindAlreadyExists},
+		{409, "409 Conflict", core.KindConflict},
+		{409, "409 Conflict: Resource was modified in the meantime", core.KindConflict},
+		{429, "429 Too Many Requests", core.KindNetwork},
+		{500, "500 Internal Server Error", core.KindNetwork},
+		{502, "502 Bad Gateway", core.KindNetwork},
+		{503, "503 Service Unavailable", core.KindNetwork},
+		{504, "504 Gateway Timeout", core.KindNetwork},
+		{302, "302 Found", core.KindUnknown},
+		{418, "418 I'm a teapot", core.KindUnknown},
+	}
+	for _, tt := range tests {
+		if got := kindFromStatus(tt.status, tt.message); got != tt.want {
+			t.Errorf("kindFromStatus(%d, %q) = %s, want %s", tt.status, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	jsonErr := json.Unmarshal([]byte("<html>"), &struct{}{})
+	tests := []struct {
+		name string
+		err  error
+		want core.ErrorKind
+	}{
+		{"http status", &gocloak.APIError{Code: 404, Message: "404 Not Found"}, core.KindNotFound},
+		{"wrapped http status", errors.Wrap(&gocloak.APIError{Code: 403, Message: "403 Forbidden"}, "failed"), core.KindForbidden},
+		{"connection refused", &gocloak.APIError{Message: "could not get token: dial tcp 127.0.0.1:1: connect: connection refused"}, core.KindNetwork},
+		{"unknown host", &gocloak.APIError{Message: "could not get users: dial tcp: lookup kc.invalid: no such host"}, core.KindNetwork},
+		{"timeout", &gocloak.APIError{Message: "could not get users: request timed out after 15s"}, core.KindNetwork},
+		{"canceled", &gocloak.APIError{Message: "could not get users: context canceled"}, core.KindInterrupted},
+		{"malformed response", &gocloak.APIError{Message: "could not get users: " + jsonErr.Error()}, core.KindUnknown},
+		{"empty response", &gocloak.APIError{Message: "empty response"}, core.KindUnknown},
+		{"net error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, core.KindNetwork},
+		{"json error", jsonErr, core.KindUnknown},
+		{"context canceled", context.Canceled, core.KindInterrupted},
+		{"already classified", core.NewError(core.KindValidation, "invalid"), core.KindValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := core.KindOf(classifyError(tt.err)); got != tt.want {
+				t.Errorf("kind of classifyError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+	if classifyError(nil) != nil {
+		t.Error("classifyError(nil) is not nil")
+	}
+}
@@ -0,0 +1,210 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+	"github.com/dgrijalva/jwt-go/v4"
+	"github.com/pkg/errors"
+)
+
+// certsCacheTTL is the time after which cached realm keys are fetched again.
+const certsCacheTTL = 10 * time.Minute
+
+// certsCache caches the JSON Web Key Sets of realms. The key sets are looked up
+// by the realm URL. They are kept in memory and in the cache directory next to
+// the sessions, so that they can be reused by later invocations.
+var certsCache = &jwksCache{entries: make(map[string]jwksCacheEntry)}
+
+type jwksCache struct {
+	mu      sync.Mutex
+	entries map[string]jwksCacheEntry
+}
+
+type jwksCacheEntry struct {
+	Keys    []gocloak.CertResponseKey `json:"keys"`
+	Fetched time.Time                 `json:"fetched"`
+}
+
+// fresh returns true, if the entry was fetched less than the TTL ago.
+func (e jwksCacheEntry) fresh() bool {
+	age := time.Since(e.Fetched)
+	return age >= 0 && age < certsCacheTTL
+}
+
+// keys returns the cached key set of a realm or fetches it, if it isn't cached
+// or the cached one is outdated.
+func (c *jwksCache) keys(ctx context.Context, gocloakClient *gocloak.GoCloak, url, realm string, forceFetch bool) ([]gocloak.CertResponseKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cacheKey := strings.TrimRight(url, "/") + "/" + realm
+	if !forceFetch {
+		entry, ok := c.entries[cacheKey]
+		if !ok {
+			entry, ok = readJWKSCacheFile(cacheKey)
+		}
+		if ok && entry.fresh() {
+			c.entries[cacheKey] = entry
+			return entry.Keys, nil
+		}
+	}
+
+	certs, err := (*gocloakClient).GetCerts(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+	if certs.Keys == nil {
+		return nil, errors.New("realm doesn't publish any keys")
+	}
+	entry := jwksCacheEntry{Keys: *certs.Keys, Fetched: time.Now()}
+	c.entries[cacheKey] = entry
+	// the cache is only an optimization, failing to persist it is not an error
+	_ = writeJWKSCacheFile(cacheKey, entry)
+
+	return entry.Keys, nil
+}
+
+// jwksCacheFilePath returns the path of the file, in which the key set of the
+// given realm URL is cached.
+func jwksCacheFilePath(cacheKey string) string {
+	hash := sha256.Sum256([]byte(cacheKey))
+	return filepath.Join(jsonfile.CacheDir(), "jwks", hex.EncodeToString(hash[:])+".json")
+}
+
+// readJWKSCacheFile reads a cached key set from disk. Returns false, if the
+// file doesn't exist or cannot be read.
+func readJWKSCacheFile(cacheKey string) (jwksCacheEntry, bool) {
+	var entry jwksCacheEntry
+	data, err := ioutil.ReadFile(jwksCacheFilePath(cacheKey))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeJWKSCacheFile writes a key set to disk. The file is replaced
+// atomically, so that concurrent readers never see a partial file.
+func writeJWKSCacheFile(cacheKey string, entry jwksCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := jwksCacheFilePath(cacheKey)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// verifyToken verifies the signature of a token against the key set of the
+// given realm. If the signing key cannot be found in a cached key set, the key
+// set is fetched again, because the realm keys might have been rotated.
+func verifyToken(ctx context.Context, gocloakClient *gocloak.GoCloak, url, realm, token string) error {
+	decoded, _, err := jwt.NewParser().ParseUnverified(token, &jwt.MapClaims{})
+	if err != nil {
+		return errors.Wrap(err, "cannot decode token")
+	}
+	kid, _ := decoded.Header["kid"].(string)
+
+	var usedKey *gocloak.CertResponseKey
+	for _, forceFetch := range []bool{false, true} {
+		keys, err := certsCache.keys(ctx, gocloakClient, url, realm, forceFetch)
+		if err != nil {
+			return errors.Wrap(err, "cannot retrieve realm keys")
+		}
+		if usedKey = findKey(keys, kid); usedKey != nil {
+			break
+		}
+	}
+	if usedKey == nil {
+		return errors.Errorf("no realm key matches the key ID '%s'", kid)
+	}
+
+	return verifySignature(token, usedKey)
+}
+
+// verifySignature verifies the signature of a token using the given key. The
+// claims aren't validated, so that the signature of an expired token can be
+// verified as well. Only RSA keys are supported.
+func verifySignature(token string, key *gocloak.CertResponseKey) error {
+	publicKey, err := rsaPublicKey(key)
+	if err != nil {
+		return err
+	}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	_, err = parser.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return publicKey, nil
+		}
+		return nil, errors.Errorf("unexpected signing method '%v'", token.Header["alg"])
+	})
+	return err
+}
+
+// rsaPublicKey decodes the public key of a JSON Web Key. Returns an error, if
+// it isn't a RSA key.
+func rsaPublicKey(key *gocloak.CertResponseKey) (*rsa.PublicKey, error) {
+	kty := ""
+	if key.Kty != nil {
+		kty = *key.Kty
+	}
+	if kty != "RSA" || key.N == nil || key.E == nil {
+		return nil, errors.Errorf("unsupported key type '%s', only RSA keys are supported", kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(*key.N)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode key modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(*key.E)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode key exponent")
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 2 || exponent.Int64() > math.MaxInt32 {
+		return nil, errors.New("invalid RSA key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// findKey returns the signing key with the given key ID or `nil`, if no key
+// matches.
+func findKey(keys []gocloak.CertResponseKey, kid string) *gocloak.CertResponseKey {
+	for i := range keys {
+		key := keys[i]
+		if key.Kid != nil && *key.Kid == kid && (key.Use == nil || *key.Use == "sig") {
+			return &key
+		}
+	}
+	return nil
+}
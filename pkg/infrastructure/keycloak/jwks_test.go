@@ -0,0 +1,67 @@
+package keycloak
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/v8"
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+// newRSAKey returns a RSA private key and its public key as JSON Web Key.
+func newRSAKey(t *testing.T) (*rsa.PrivateKey, *gocloak.CertResponseKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes())
+	return privateKey, &gocloak.CertResponseKey{Kid: gocloak.StringP("k1"), Kty: gocloak.StringP("RSA"), N: &n, E: &e}
+}
+
+func signToken(t *testing.T, privateKey *rsa.PrivateKey, expiresIn time.Duration) string {
+	claims := jwt.StandardClaims{ExpiresAt: jwt.At(time.Now().Add(expiresIn))}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifySignature(t *testing.T) {
+	privateKey, key := newRSAKey(t)
+	otherPrivateKey, _ := newRSAKey(t)
+	ecKey := &gocloak.CertResponseKey{Kid: gocloak.StringP("k2"), Kty: gocloak.StringP("EC")}
+
+	tests := []struct {
+		name    string
+		token   string
+		key     *gocloak.CertResponseKey
+		wantErr string
+	}{
+		{"valid", signToken(t, privateKey, time.Hour), key, ""},
+		{"expired", signToken(t, privateKey, -time.Hour), key, ""},
+		{"other key", signToken(t, otherPrivateKey, time.Hour), key, "signature is invalid"},
+		{"EC key", signToken(t, privateKey, time.Hour), ecKey, "unsupported key type 'EC'"},
+		{"key without type", signToken(t, privateKey, time.Hour), &gocloak.CertResponseKey{N: key.N}, "unsupported key type ''"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.token, tt.key)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifySignature() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("verifySignature() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package keycloak
 
 import (
+	"context"
+	"net/http"
+	"strings"
+
 	"github.com/Nerzal/gocloak/v8"
 	"github.com/aisbergg/keycli/pkg/core"
 	"github.com/dgrijalva/jwt-go/v4"
@@ -8,73 +12,135 @@ import (
 )
 
 // keyclaokSessionProvider implements `core.SessionProvider`
-type keyclaokSessionProvider struct{}
+type keyclaokSessionProvider struct {
+	http HTTPOptions
+}
 
-// NewKeycloakSessionProvider initializes a new `keyclaokSessionProvider`.
-func NewKeycloakSessionProvider() core.SessionProvider {
-	return &keyclaokSessionProvider{}
+// NewKeycloakSessionProvider initializes a new `keyclaokSessionProvider`, that
+// sends its requests using the given options.
+func NewKeycloakSessionProvider(opts HTTPOptions) core.SessionProvider {
+	return &keyclaokSessionProvider{http: opts}
 }
 
-func (sp *keyclaokSessionProvider) CreateWithUsernamePassword(name, url, realm, clientID, user, password string, skipVerify bool) (*core.Session, error) {
+func (sp *keyclaokSessionProvider) CreateWithUsernamePassword(ctx context.Context, opts core.SessionOptions, user, password string) (*core.Session, error) {
 	topt := gocloak.TokenOptions{
-		ClientID:  gocloak.StringP(clientID),
+		ClientID:  gocloak.StringP(opts.ClientID),
 		GrantType: gocloak.StringP("password"),
 		Username:  &user,
 		Password:  &password,
 	}
-	return sp.create(name, url, realm, skipVerify, topt)
+	return sp.create(ctx, opts, topt)
 }
 
-func (sp *keyclaokSessionProvider) CreateWithClientSecret(name, url, realm, clientID, secret string, skipVerify bool) (*core.Session, error) {
+func (sp *keyclaokSessionProvider) CreateWithClientSecret(ctx context.Context, opts core.SessionOptions, secret string) (*core.Session, error) {
 	topt := gocloak.TokenOptions{
-		ClientID:     &clientID,
+		ClientID:     gocloak.StringP(opts.ClientID),
 		ClientSecret: &secret,
-		GrantType:    gocloak.StringP("client_credentials"),
+		GrantType:    gocloak.StringP(core.GrantClientCredentials),
 	}
-	return sp.create(name, url, realm, skipVerify, topt)
+	return sp.create(ctx, opts, topt)
 }
 
 // create sends an auth request to Keycloak and returns a new session.
-func (sp *keyclaokSessionProvider) create(name, url, realm string, skipVerify bool, tokenOptions gocloak.TokenOptions) (*core.Session, error) {
-	ctx, cancel := createContext()
-	defer cancel()
-	gocloakClient := createGoclaokClient(url, skipVerify)
+func (sp *keyclaokSessionProvider) create(ctx context.Context, opts core.SessionOptions, tokenOptions gocloak.TokenOptions) (*core.Session, error) {
+	conn := connection{url: opts.URL, skipVerify: opts.SkipVerify, proxy: opts.Proxy, noProxy: opts.NoProxy, http: sp.http}
+	basePath, err := detectBasePath(ctx, conn, opts.Realm)
+	if err != nil {
+		return nil, err
+	}
+	conn.basePath = basePath
+	gocloakClient := createGoclaokClient(conn)
 
-	token, err := (*gocloakClient).GetToken(ctx, realm, tokenOptions)
+	// the openid scope is requested, so that an ID token is issued as well
+	scopes := []string{"openid"}
+	if opts.Offline {
+		scopes = append(scopes, "offline_access")
+	}
+	tokenOptions.Scopes = &scopes
+
+	token, err := (*gocloakClient).GetToken(ctx, opts.Realm, tokenOptions)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get token")
+		return nil, errors.Wrap(classifyError(err), "failed to get token")
 	}
 
 	session := core.Session{
-		ClientID:   *tokenOptions.ClientID,
-		Token:      *token,
-		Created:    *jwt.Now(),
-		Name:       name,
-		URL:        url,
-		Realm:      realm,
-		SkipVerify: skipVerify,
+		ClientID:    *tokenOptions.ClientID,
+		Token:       *token,
+		Created:     *jwt.Now(),
+		Name:        opts.Name,
+		URL:         opts.URL,
+		Realm:       opts.Realm,
+		SkipVerify:  opts.SkipVerify,
+		Offline:     opts.Offline,
+		TargetRealm: opts.TargetRealm,
+		GrantType:   *tokenOptions.GrantType,
+		Proxy:       opts.Proxy,
+		NoProxy:     opts.NoProxy,
+		Server: core.ServerInfo{
+			BasePath: basePath,
+			Version:  detectVersion(ctx, gocloakClient, opts.URL, basePath, token.AccessToken),
+		},
 	}
 	return &session, nil
 }
 
-// End ends the session.
-func (sp *keyclaokSessionProvider) End(session *core.Session) error {
-	ctx, cancel := createContext()
-	defer cancel()
-	gocloakClient := createGoclaokClient(session.URL, session.SkipVerify)
+// End ends the session. Logging out with an offline token also revokes the
+// offline session.
+func (sp *keyclaokSessionProvider) End(ctx context.Context, session *core.Session) error {
+	gocloakClient := createGoclaokClient(sessionConnection(session, sp.http))
 
 	err := (*gocloakClient).Logout(ctx, session.ClientID, "", session.Realm, session.Token.RefreshToken)
 	if err != nil {
-		return errors.Wrap(err, "failed to logout")
+		return errors.Wrap(classifyError(err), "failed to logout")
 	}
 
 	return nil
 }
 
-func (sp *keyclaokSessionProvider) Refresh(session *core.Session) (bool, error) {
-	ctx, cancel := createContext()
-	defer cancel()
-	gocloakClient := createGoclaokClient(session.URL, session.SkipVerify)
+// Revoke revokes the refresh and access tokens of the session. Tokens, that
+// are already expired, are skipped.
+func (sp *keyclaokSessionProvider) Revoke(ctx context.Context, session *core.Session) error {
+	if err := session.Server.RequireCapability(core.CapabilityTokenRevocation); err != nil {
+		return err
+	}
+	gocloakClient := createGoclaokClient(sessionConnection(session, sp.http))
+
+	tokens := []struct {
+		token     string
+		hint      string
+		canRevoke bool
+	}{
+		{session.Token.RefreshToken, "refresh_token", session.Token.RefreshToken != "" && session.CanBeRefreshed()},
+		{session.Token.AccessToken, "access_token", !session.IsExpired(0)},
+	}
+	for _, t := range tokens {
+		if !t.canRevoke {
+			continue
+		}
+		resp, err := (*gocloakClient).RestyClient().R().
+			SetContext(ctx).
+			SetFormData(map[string]string{
+				"client_id":       session.ClientID,
+				"token":           t.token,
+				"token_type_hint": t.hint,
+			}).
+			Post(realmURL(session.URL, session.Server.BasePath, session.Realm, "protocol/openid-connect/revoke"))
+		if err != nil {
+			return errors.Wrapf(classifyError(err), "failed to revoke %s", t.hint)
+		}
+		if resp.StatusCode() == http.StatusNotFound {
+			return core.NewError(core.KindValidation, "the server doesn't support token revocation")
+		}
+		if resp.IsError() {
+			return core.NewError(kindFromStatus(resp.StatusCode(), resp.String()), "failed to revoke %s: %s: %s", t.hint, resp.Status(), strings.TrimSpace(resp.String()))
+		}
+	}
+
+	return nil
+}
+
+func (sp *keyclaokSessionProvider) Refresh(ctx context.Context, session *core.Session) (bool, error) {
+	gocloakClient := createGoclaokClient(sessionConnection(session, sp.http))
 
 	token, err := (*gocloakClient).GetToken(ctx, session.Realm, gocloak.TokenOptions{
 		ClientID:     &session.ClientID,
@@ -82,7 +148,7 @@ func (sp *keyclaokSessionProvider) Refresh(session *core.Session) (bool, error)
 		RefreshToken: &session.Token.RefreshToken,
 	})
 	if err != nil {
-		return false, errors.Wrap(err, "failed to refresh token")
+		return false, errors.Wrap(classifyError(err), "failed to refresh token")
 	}
 
 	// update token information
@@ -92,3 +158,11 @@ func (sp *keyclaokSessionProvider) Refresh(session *core.Session) (bool, error)
 
 	return true, nil
 }
+
+// Verify verifies the signature of the access token against the JSON Web Key
+// Set of the realm. The key set is cached on disk for a few minutes.
+func (sp *keyclaokSessionProvider) Verify(ctx context.Context, session *core.Session) error {
+	gocloakClient := createGoclaokClient(sessionConnection(session, sp.http))
+
+	return verifyToken(ctx, gocloakClient, session.URL, session.Realm, session.Token.AccessToken)
+}
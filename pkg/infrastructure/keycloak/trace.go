@@ -0,0 +1,246 @@
+package keycloak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceMaxBody is the maximum number of bytes of a body written to the trace.
+const traceMaxBody = 64 * 1024
+
+// redacted replaces secret values in the trace.
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are the headers, whose values are redacted in the trace.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields are the form fields and JSON keys, whose values are redacted
+// in the trace.
+var sensitiveFields = map[string]bool{
+	"password":                true,
+	"client_secret":           true,
+	"secret":                  true,
+	"token":                   true,
+	"access_token":            true,
+	"refresh_token":           true,
+	"id_token":                true,
+	"subject_token":           true,
+	"client_assertion":        true,
+	"assertion":               true,
+	"code":                    true,
+	"value":                   true,
+	"credentialData":          true,
+	"secretData":              true,
+	"registrationAccessToken": true,
+}
+
+// jwtPattern matches JSON Web Tokens, that appear outside of known fields.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// traceTransport is a `http.RoundTripper`, that writes every request and
+// response to a trace with all credentials redacted.
+type traceTransport struct {
+	next http.RoundTripper
+	mu   *sync.Mutex
+	out  io.Writer
+}
+
+// traceMu serializes the writes of all trace transports.
+var traceMu sync.Mutex
+
+// newTraceTransport wraps a transport with the trace of the given options. The
+// transport is returned as is, if tracing is disabled.
+func newTraceTransport(next http.RoundTripper, opts HTTPOptions) http.RoundTripper {
+	if opts.Trace == nil {
+		return next
+	}
+	return &traceTransport{next: next, mu: &traceMu, out: opts.Trace}
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "> %s %s\n", req.Method, redactURLQuery(req.URL))
+	writeHeaders(buf, "> ", req.Header)
+	if req.Body != nil {
+		if req.GetBody == nil {
+			buf.WriteString(">\n> [body not captured]\n")
+		} else if body, err := req.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(body)
+			body.Close()
+			writeBody(buf, "> ", req.Header.Get("Content-Type"), data)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(buf, "< error after %s: %v\n\n", elapsed, err)
+		t.write(buf.Bytes())
+		return nil, err
+	}
+
+	fmt.Fprintf(buf, "< %s %s (%s)\n", resp.Proto, resp.Status, elapsed)
+	writeHeaders(buf, "< ", resp.Header)
+	data, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr != nil {
+		// a response must not be returned along with an error
+		fmt.Fprintf(buf, "<\n< [body not captured: %v]\n\n", readErr)
+		t.write(buf.Bytes())
+		return nil, readErr
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	writeBody(buf, "< ", resp.Header.Get("Content-Type"), data)
+	buf.WriteString("\n")
+	t.write(buf.Bytes())
+
+	return resp, nil
+}
+
+// write writes a complete exchange to the trace at once.
+func (t *traceTransport) write(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.out.Write(data)
+}
+
+// writeHeaders writes the headers sorted by name with sensitive values
+// redacted.
+func writeHeaders(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				value = redactHeaderValue(value)
+			}
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+// redactHeaderValue redacts a header value, but keeps the authentication
+// scheme (e.g.: `Bearer`) for reference.
+func redactHeaderValue(value string) string {
+	if scheme := strings.SplitN(value, " ", 2); len(scheme) == 2 && !strings.Contains(scheme[0], "=") {
+		return scheme[0] + " " + redacted
+	}
+	return redacted
+}
+
+// writeBody writes a body with all sensitive values redacted. Form and JSON
+// bodies are redacted field by field, any other body is searched for tokens.
+func writeBody(w io.Writer, prefix, contentType string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	truncated := len(data) > traceMaxBody
+	var body string
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		body = redactForm(string(data))
+	case strings.Contains(contentType, "json"):
+		body = redactJSON(data)
+	default:
+		body = string(data)
+	}
+	body = jwtPattern.ReplaceAllString(body, redacted)
+	if truncated && len(body) > traceMaxBody {
+		body = body[:traceMaxBody]
+	}
+
+	fmt.Fprintf(w, "%s\n", strings.TrimSpace(prefix))
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+	if truncated {
+		fmt.Fprintf(w, "%s[body truncated, %d bytes in total]\n", prefix, len(data))
+	}
+}
+
+// redactForm redacts the sensitive fields of a URL encoded form.
+func redactForm(body string) string {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return redacted
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if sensitiveFields[key] {
+				// the placeholder is not escaped to keep it readable
+				pairs = append(pairs, url.QueryEscape(key)+"="+redacted)
+			} else {
+				pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactJSON redacts the sensitive keys of a JSON document at any depth. A
+// document, that cannot be parsed, is redacted as a whole.
+func redactJSON(data []byte) string {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		// the sensitive fields of a malformed document cannot be found
+		return redacted
+	}
+	redactJSONValue(document)
+	redactedData, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return redacted
+	}
+	return string(redactedData)
+}
+
+func redactJSONValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if sensitiveFields[key] {
+				v[key] = redacted
+				continue
+			}
+			redactJSONValue(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			redactJSONValue(child)
+		}
+	}
+}
+
+// redactURLQuery returns the URL with the password and sensitive query
+// parameters redacted.
+func redactURLQuery(u *url.URL) string {
+	redactedURL := *u
+	if redactedURL.RawQuery != "" {
+		redactedURL.RawQuery = redactForm(redactedURL.RawQuery)
+	}
+	return redactedURL.Redacted()
+}
@@ -0,0 +1,263 @@
+package keycloak
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+const (
+	// retryBaseWait is the wait time before the first retry. It doubles with
+	// every further retry.
+	retryBaseWait = 500 * time.Millisecond
+	// retryMaxWait is the maximum wait time between two attempts, including
+	// the time requested by a `Retry-After` header.
+	retryMaxWait = 30 * time.Second
+)
+
+// HTTPOptions configure the HTTP requests to Keycloak. They are passed to the
+// providers and clients on creation.
+type HTTPOptions struct {
+	// RequestTimeout is the maximum duration of a single HTTP request. A value
+	// of zero means no timeout.
+	RequestTimeout time.Duration
+	// MaxRetries is the maximum number of retries of a failed request.
+	MaxRetries int
+	// RateLimiter limits the rate of the requests of all clients using the
+	// same limiter. A nil limiter doesn't limit.
+	RateLimiter *RateLimiter
+	// Logger receives debug messages (e.g.: about retries), if not nil.
+	Logger *log.Logger
+	// Trace receives a dump of every request and response with credentials
+	// redacted, if not nil.
+	Trace io.Writer
+}
+
+// DefaultHTTPOptions returns the options used by keycli, if none are given.
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{RequestTimeout: 15 * time.Second, MaxRetries: 3}
+}
+
+// retryTransport is a `http.RoundTripper`, that limits the request rate and
+// retries failed requests with exponential backoff and jitter.
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	limiter *RateLimiter
+	logger  *log.Logger
+}
+
+// newRetryTransport wraps a transport with the rate limit and retries of the
+// given options.
+func newRetryTransport(next http.RoundTripper, opts HTTPOptions) http.RoundTripper {
+	return &retryTransport{
+		next:    next,
+		timeout: opts.RequestTimeout,
+		retries: opts.MaxRetries,
+		limiter: opts.RateLimiter,
+		logger:  opts.Logger,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTripOnce(req, attempt)
+		if attempt >= t.retries || !isRetryable(req, resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		wait := backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+			// the body must be consumed for the connection to be reused
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if wait > retryMaxWait {
+			wait = retryMaxWait
+		}
+		t.logf("%s %s failed (%s), retrying in %s (%d/%d)", req.Method, req.URL.Redacted(), reason, wait.Round(time.Millisecond), attempt+1, t.retries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTripOnce sends a single attempt of a request within the request timeout.
+// The timeout covers reading the response body as well.
+func (t *retryTransport) roundTripOnce(req *http.Request, attempt int) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	attemptReq := req.WithContext(ctx)
+	if attempt > 0 && req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.next.RoundTrip(attemptReq)
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			return nil, core.NewError(core.KindNetwork, "request timed out after %s", t.timeout)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a request, when the response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func (t *retryTransport) logf(format string, args ...interface{}) {
+	if t.logger != nil {
+		t.logger.Printf(format, args...)
+	}
+}
+
+// isRetryable returns true, if a failed request can be sent again. Requests
+// rejected by a rate limit are retried regardless of the method, because they
+// weren't processed. Other failures are retried for idempotent requests only.
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(req.Method) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isIdempotent returns true, if sending a request with the method multiple
+// times has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait time before the given retry attempt: An
+// exponentially growing duration with full jitter.
+func backoff(attempt int) time.Duration {
+	max := retryBaseWait << uint(attempt)
+	if max <= 0 || max > retryMaxWait {
+		max = retryMaxWait
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// parseRetryAfter parses the value of a `Retry-After` header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// RateLimiter spaces requests evenly to not exceed a number of requests per
+// second. A nil limiter doesn't limit.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter creates a limiter for the given number of requests per
+// second. Returns nil, if the rate is not positive.
+func NewRateLimiter(rate float64) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the next request may be sent or the context is done.
+func (l *RateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
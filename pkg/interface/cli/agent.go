@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/agent"
+	"github.com/pkg/errors"
+)
+
+// Agent is the implementation of the agent command. It runs a session agent
+// listening on a unix socket, until the context is done (e.g.: on SIGINT or
+// SIGTERM).
+func Agent(ctx context.Context, c *Container, socketPath string) error {
+	if err := agent.CheckPlatform(); err != nil {
+		return core.WrapError(core.KindValidation, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return errors.Errorf("cannot create directory for agent socket '%s': %v", socketPath, err)
+	}
+	// remove a stale socket of a previous agent
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return errors.Errorf("another agent is already listening on '%s'", socketPath)
+	}
+	os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return errors.Errorf("cannot listen on agent socket '%s': %v", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return errors.Errorf("cannot restrict permissions of agent socket '%s': %v", socketPath, err)
+	}
+
+	// print the environment in a shell compatible way, like ssh-agent does
+	c.Printer.Printf("%s=%s; export %s;\n", agent.SocketEnvVar, socketPath, agent.SocketEnvVar)
+	c.Printer.Printf("echo Agent pid %d;\n", os.Getpid())
+
+	// the agent itself accesses the session repository directly
+	server := agent.NewServer(c.NewAgentSessionService)
+	go server.RefreshLoop(ctx)
+	if err := server.Serve(ctx, listener); err != nil {
+		return errors.Wrap(err, "agent failed")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aisbergg/keycli/pkg/client"
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+	"github.com/pkg/errors"
+)
+
+// apiPageSize is the number of items requested per page, when paginating.
+const apiPageSize = 100
+
+// APIResult is the result of the api command.
+type APIResult struct {
+	Response *keycloak.APIResponse
+}
+
+// API is the implementation of the api command. It sends a raw request to the
+// admin REST API and returns the response. HTTP error responses are returned
+// as errors.
+// The placeholder `{realm}` is replaced with the given realm or, if empty, the
+// target realm of the session.
+func API(ctx context.Context, c *Container, name, realm, method, path string, fields []string, input string, paginate bool) (*APIResult, error) {
+	adminClient, err := client.New(ctx, c.NewSessionService(), name, c.HTTPOptions)
+	if err != nil {
+		return nil, err
+	}
+	session := adminClient.Session()
+
+	req := keycloak.APIRequest{
+		Method: strings.ToUpper(method),
+		Path:   strings.ReplaceAll(path, "{realm}", url.PathEscape(session.ResolveRealm(realm))),
+		Query:  url.Values{},
+	}
+
+	// parse fields
+	params := make(map[string]string, len(fields))
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, core.NewError(core.KindValidation, "invalid field '%s', expected key=value", field)
+		}
+		params[kv[0]] = kv[1]
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodDelete || req.Method == http.MethodHead {
+		for k, v := range params {
+			req.Query.Set(k, v)
+		}
+	} else if len(params) > 0 {
+		if req.Body, err = json.Marshal(params); err != nil {
+			return nil, err
+		}
+	}
+
+	// read request body
+	if input != "" {
+		if req.Body != nil {
+			return nil, core.NewError(core.KindValidation, "--input cannot be combined with fields for requests with a body")
+		}
+		if req.Body, err = readInput(c.input(), input); err != nil {
+			return nil, err
+		}
+	}
+
+	// send request
+	var resp *keycloak.APIResponse
+	if paginate {
+		resp, err = adminClient.DoPaginated(ctx, req, apiPageSize)
+	} else {
+		resp, err = adminClient.Do(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, core.NewError(resp.Kind(), "%s %s: %s\n%s", req.Method, req.Path, resp.Status, prettyJSON(resp.Body))
+	}
+
+	return &APIResult{Response: resp}, nil
+}
+
+// Print writes the body of the response with JSON indented.
+func (r *APIResult) Print(p *Printer) error {
+	if body := prettyJSON(r.Response.Body); len(body) > 0 {
+		p.Println(string(body))
+	}
+	return nil
+}
+
+// readInput reads the content of a file or of the given input, if path is '-'.
+func readInput(in io.Reader, path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(in)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.Errorf("cannot read input '%s': %v", path, err)
+	}
+	return data, nil
+}
+
+// prettyJSON indents JSON data. Other data is returned unchanged.
+func prettyJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return bytes.TrimSpace(data)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/agent"
+	"github.com/aisbergg/keycli/pkg/infrastructure/envvar"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+)
+
+// Container holds the dependencies of the commands. It is built once on
+// startup and passed to every command, so that the commands can be run with
+// other dependencies (e.g.: in tests).
+type Container struct {
+	// NewSessionService creates the session service used by the commands. Each
+	// call returns a new service, because a session repository holds the lock
+	// of a single session only.
+	NewSessionService func() core.SessionService
+	// NewAgentSessionService creates the session service of the agent, which
+	// accesses the session repository directly.
+	NewAgentSessionService func() core.SessionService
+	// In is the input read by commands, when `-` is given as the input file,
+	// and by prompts.
+	In io.Reader
+	// ReadSecret reads a secret from the terminal without echoing it.
+	ReadSecret func() ([]byte, error)
+	// Printer writes the output of the commands.
+	Printer *Printer
+	// HTTPOptions configure the requests to Keycloak. They are read whenever a
+	// service is created.
+	HTTPOptions keycloak.HTTPOptions
+
+	// in buffers In, so that consecutive reads don't lose any input
+	in *bufio.Reader
+}
+
+// NewContainer creates the container with the default dependencies, that reads
+// from stdin and writes to stdout and stderr.
+func NewContainer() *Container {
+	c := &Container{
+		In:          os.Stdin,
+		ReadSecret:  readTerminalSecret,
+		Printer:     NewPrinter(os.Stdout, os.Stderr),
+		HTTPOptions: keycloak.DefaultHTTPOptions(),
+	}
+	c.NewSessionService = func() core.SessionService {
+		return newSessionService(c.HTTPOptions)
+	}
+	c.NewAgentSessionService = func() core.SessionService {
+		return core.NewSessionService(jsonfile.NewJSONFileSessionRepository(), keycloak.NewKeycloakSessionProvider(c.HTTPOptions))
+	}
+	return c
+}
+
+// newSessionService initializes the session service used by the commands. If
+// the environment provides a session, the stateless mode is used, in which no
+// session data is read from or written to disk. If the environment points to
+// a session agent, sessions are loaded from the agent. Project-local sessions
+// are always loaded from the repository, because the agent resolves session
+// names relative to its own working directory.
+func newSessionService(opts keycloak.HTTPOptions) core.SessionService {
+	sessionProvider := keycloak.NewKeycloakSessionProvider(opts)
+	if envvar.IsConfigured() {
+		return core.NewSessionService(envvar.NewEnvSessionRepository(sessionProvider), sessionProvider)
+	}
+	sessionRepository := jsonfile.NewJSONFileSessionRepository()
+	socketPath := os.Getenv(agent.SocketEnvVar)
+	if socketPath != "" && (os.Getenv(jsonfile.HomeEnvVar) != "" || jsonfile.ProjectDir() == "") {
+		return core.NewSessionServiceWithAgent(sessionRepository, sessionProvider, agent.NewClient(socketPath))
+	}
+	return core.NewSessionService(sessionRepository, sessionProvider)
+}
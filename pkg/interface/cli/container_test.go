@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+)
+
+// fakeSessionService records the sessions imported, ended and removed. Methods not
+// overridden panic.
+type fakeSessionService struct {
+	core.SessionService
+	session  *core.Session
+	loadErr  error
+	revoked  bool
+	imported []*core.Session
+	removed  []string
+}
+
+func (f *fakeSessionService) Load(ctx context.Context, name string) (*core.Session, error) {
+	if f.loadErr != nil {
+		return nil, f.loadErr
+	}
+	session := *f.session
+	return &session, nil
+}
+
+func (f *fakeSessionService) End(ctx context.Context, session *core.Session, force, revoke bool) (bool, error) {
+	f.removed = append(f.removed, session.Name)
+	return revoke && f.revoked, nil
+}
+
+func (f *fakeSessionService) LoadRefresh(ctx context.Context, name string, minValidity time.Duration) (*core.Session, error) {
+	session := *f.session
+	return &session, nil
+}
+
+func (f *fakeSessionService) Import(ctx context.Context, session *core.Session, overwrite bool) error {
+	f.imported = append(f.imported, session)
+	return nil
+}
+
+func (f *fakeSessionService) Remove(ctx context.Context, name string) error {
+	f.removed = append(f.removed, name)
+	return nil
+}
+
+func (f *fakeSessionService) Location(name string) string {
+	return "/sessions/" + name + ".json"
+}
+
+// newTestContainer creates a container, that reads the given input and
+// secrets and writes to buffers.
+func newTestContainer(service core.SessionService, input string, secrets ...string) (*Container, *bytes.Buffer, *bytes.Buffer) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	c := &Container{
+		NewSessionService: func() core.SessionService { return service },
+		In:                strings.NewReader(input),
+		Printer:           NewPrinter(out, errOut),
+	}
+	c.ReadSecret = func() ([]byte, error) {
+		if len(secrets) == 0 {
+			return nil, core.NewError(core.KindValidation, "no secret left")
+		}
+		secret := secrets[0]
+		secrets = secrets[1:]
+		return []byte(secret), nil
+	}
+	return c, out, errOut
+}
+
+func TestPrompt(t *testing.T) {
+	c, out, errOut := newTestContainer(nil, " https://sso.example.org \nadmin\n", "s3cret-password")
+
+	url, err := c.Prompt("Keycloak URL")
+	if err != nil || url != "https://sso.example.org" {
+		t.Fatalf("Prompt() = %q, %v, want the first line", url, err)
+	}
+	user, err := c.Prompt("Keycloak Admin User")
+	if err != nil || user != "admin" {
+		t.Fatalf("Prompt() = %q, %v, want the second line", user, err)
+	}
+	password, err := c.PromptSecret("Keycloak Admin Password")
+	if err != nil || password != "s3cret-password" {
+		t.Fatalf("PromptSecret() = %q, %v, want the secret", password, err)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("prompts written to the output: %q", out.String())
+	}
+	want := "Keycloak URL: Keycloak Admin User: Keycloak Admin Password: \n"
+	if errOut.String() != want {
+		t.Errorf("error output = %q, want %q", errOut.String(), want)
+	}
+	if Redact(password) != "[REDACTED]" {
+		t.Errorf("prompted secret is not redacted")
+	}
+}
+
+func TestReadSecretFromStdinAfterPrompt(t *testing.T) {
+	c, _, _ := newTestContainer(nil, "https://sso.example.org\nstdin-password\n")
+
+	if _, err := c.Prompt("Keycloak URL"); err != nil {
+		t.Fatal(err)
+	}
+	password, err := c.ReadSecretFromStdin()
+	if err != nil || password != "stdin-password" {
+		t.Fatalf("ReadSecretFromStdin() = %q, %v, want the line after the prompt", password, err)
+	}
+}
+
+func TestSessionImport(t *testing.T) {
+	data, err := jsonfile.ExportSession(&core.Session{Name: "exported"}, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &fakeSessionService{}
+	c, _, errOut := newTestContainer(service, data, "passphrase")
+
+	result, err := SessionImport(context.Background(), c, "-", "imported", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(service.imported) != 1 || service.imported[0].Name != "imported" {
+		t.Fatalf("imported sessions = %v, want the renamed session", service.imported)
+	}
+	if result.Location != "/sessions/imported.json" {
+		t.Errorf("Location = %q, want the location of the service", result.Location)
+	}
+	if err := result.Print(c.Printer); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(errOut.String(), "Passphrase: ") || !strings.Contains(errOut.String(), "/sessions/imported.json") {
+		t.Errorf("error output = %q, want the prompt and the location", errOut.String())
+	}
+}
+
+func TestLogoutForce(t *testing.T) {
+	tests := []struct {
+		name        string
+		loadErr     error
+		force       bool
+		wantRemoved bool
+	}{
+		{"corrupted with force", core.NewError(core.KindValidation, "session is corrupted"), true, true},
+		{"corrupted without force", core.NewError(core.KindValidation, "session is corrupted"), false, false},
+		{"missing with force", core.NewError(core.KindNotFound, "session does not exist"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeSessionService{loadErr: tt.loadErr}
+			c, _, _ := newTestContainer(service, "")
+
+			result, err := Logout(context.Background(), c, "broken", tt.force, false)
+			if tt.wantRemoved {
+				if err != nil || !result.Removed || len(service.removed) != 1 {
+					t.Fatalf("Logout() = %v, %v, removed %v, want the session removed", result, err, service.removed)
+				}
+				return
+			}
+			if err == nil || len(service.removed) != 0 {
+				t.Fatalf("Logout() = %v, %v, removed %v, want an error", result, err, service.removed)
+			}
+		})
+	}
+}
+
+func TestLogoutRevoke(t *testing.T) {
+	tests := []struct {
+		name    string
+		revoked bool
+		want    string
+	}{
+		{"revoked", true, "Revoked the tokens of session 'test'"},
+		{"revocation failed", false, "Failed to revoke the tokens of session 'test'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeSessionService{session: &core.Session{Name: "test"}, revoked: tt.revoked}
+			c, _, errOut := newTestContainer(service, "")
+
+			result, err := Logout(context.Background(), c, "test", true, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := result.Print(c.Printer); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(errOut.String(), tt.want) {
+				t.Errorf("error output = %q, want %q", errOut.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// Exit codes of the program. They are part of the public interface and must
+// not be changed.
+const (
+	ExitOK            = 0
+	ExitUnknown       = 1
+	ExitValidation    = 2
+	ExitNotFound      = 3
+	ExitAlreadyExists = 4
+	ExitUnauthorized  = 5
+	ExitForbidden     = 6
+	ExitConflict      = 7
+	ExitNetwork       = 8
+	ExitInterrupted   = 130
+)
+
+// exitCodes maps the error kinds to exit codes.
+var exitCodes = map[core.ErrorKind]int{
+	core.KindUnknown:       ExitUnknown,
+	core.KindValidation:    ExitValidation,
+	core.KindNotFound:      ExitNotFound,
+	core.KindAlreadyExists: ExitAlreadyExists,
+	core.KindUnauthorized:  ExitUnauthorized,
+	core.KindForbidden:     ExitForbidden,
+	core.KindConflict:      ExitConflict,
+	core.KindNetwork:       ExitNetwork,
+	core.KindInterrupted:   ExitInterrupted,
+}
+
+// ExitCode returns the exit code for an error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if code, ok := exitCodes[core.KindOf(err)]; ok {
+		return code
+	}
+	return ExitUnknown
+}
+
+// ErrorJSON formats an error as a JSON document for machine consumption. The
+// message must be redacted already.
+func ErrorJSON(err error, message string) string {
+	document := struct {
+		Error struct {
+			Kind     core.ErrorKind `json:"kind"`
+			Message  string         `json:"message"`
+			ExitCode int            `json:"exit_code"`
+		} `json:"error"`
+	}{}
+	document.Error.Kind = core.KindOf(err)
+	document.Error.Message = message
+	document.Error.ExitCode = ExitCode(err)
+	data, _ := json.Marshal(document)
+	return string(data)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"net/url"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// ValidateProxyURL checks the URL of an HTTP proxy. The password of the proxy
+// URL is registered as secret, so that it is redacted from any output.
+func ValidateProxyURL(proxy string) error {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return core.NewError(core.KindValidation, "invalid proxy URL '%s'", redactURL(proxy))
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return core.NewError(core.KindValidation, "invalid proxy URL '%s', the scheme must be http, https or socks5", redactURL(proxy))
+	}
+	if proxyURL.Host == "" {
+		return core.NewError(core.KindValidation, "invalid proxy URL '%s', the host is missing", redactURL(proxy))
+	}
+	if password, ok := proxyURL.User.Password(); ok {
+		RegisterSecret(password)
+	}
+	return nil
+}
+
+// redactURL returns the URL with the password replaced.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "[REDACTED]"
+	}
+	return parsed.Redacted()
+}
+
+// formatProxy returns a human readable description of the proxy settings of a
+// session. Credentials are redacted.
+func formatProxy(session *core.Session) string {
+	switch {
+	case session.NoProxy:
+		return "none"
+	case session.Proxy != "":
+		return redactURL(session.Proxy)
+	default:
+		return "from environment"
+	}
+}
@@ -1,32 +1,47 @@
 package cli
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/aisbergg/keycli/pkg/core"
-	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
-	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
 )
 
+// LoginResult is the result of the login command.
+type LoginResult struct {
+	Session *core.Session
+	// Location describes where the session was stored (e.g.: the path of the
+	// session file).
+	Location string
+}
+
 // Login is the implementation of the login command.
-func Login(name, url, realm, clientID, secretKey, user, password string, skipVerify bool) error {
-	sessionRepository := jsonfile.NewJSONFileSessionRepository()
-	sessionProvider := keycloak.NewKeycloakSessionProvider()
-	sessionService := core.NewSessionService(sessionRepository, sessionProvider)
+func Login(ctx context.Context, c *Container, opts core.SessionOptions, secretKey, user, password string) (*LoginResult, error) {
+	sessionService := c.NewSessionService()
 
+	var session *core.Session
 	var err error
 	if secretKey != "" {
-		_, err = sessionService.CreateWithClientSecret(name, url, realm, clientID, secretKey, skipVerify)
+		session, err = sessionService.CreateWithClientSecret(ctx, opts, secretKey)
 	} else {
-		_, err = sessionService.CreateWithUsernamePassword(name, url, realm, clientID, user, password, skipVerify)
+		session, err = sessionService.CreateWithUsernamePassword(ctx, opts, user, password)
 	}
-
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fmt.Printf("Created session '%s'.\nYour session was stored unencrypted in %s\n"+
-		"When you are done, you can end the session by using the 'logout' command.\n",
-		name, jsonfile.PathFromName(name))
 
+	return &LoginResult{Session: session, Location: sessionService.Location(opts.Name)}, nil
+}
+
+// Print writes the result for humans.
+func (r *LoginResult) Print(p *Printer) error {
+	session := r.Session
+	if session.Proxy != "" {
+		p.Messagef("Connected through proxy %s", formatProxy(session))
+	}
+	p.Messagef("Detected Keycloak %s (%s, base path %s)",
+		formatServerVersion(session.Server), session.Server.Flavour(), formatBasePath(session.Server.BasePath))
+	p.Messagef("Created session '%s'.\nYour session was stored unencrypted in %s\n"+
+		"When you are done, you can end the session by using the 'logout' command.",
+		session.Name, r.Location)
 	return nil
 }
@@ -1,28 +1,174 @@
 package cli
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/pkg/errors"
+	"sync"
 
 	"github.com/aisbergg/keycli/pkg/core"
-	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
-	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+	"github.com/pkg/errors"
 )
 
+// LogoutResult is the result of the logout command.
+type LogoutResult struct {
+	Name string
+	// Revoked is true, if the tokens were revoked explicitly.
+	Revoked bool
+	// RevokeFailed is true, if the tokens couldn't be revoked and the session
+	// was ended forcefully without revoking them.
+	RevokeFailed bool
+	// Offline is true, if an offline session was ended.
+	Offline bool
+	// Removed is true, if the session couldn't be loaded and was removed
+	// without ending it.
+	Removed bool
+}
+
 // Logout is the implementation of the logout command.
-func Logout(name string, force bool) error {
-	sessionRepository := jsonfile.NewJSONFileSessionRepository()
-	sessionProvider := keycloak.NewKeycloakSessionProvider()
-	sessionService := core.NewSessionService(sessionRepository, sessionProvider)
-	session, err := sessionService.Load(name)
+func Logout(ctx context.Context, c *Container, name string, force, revoke bool) (*LogoutResult, error) {
+	sessionService := c.NewSessionService()
+	session, err := sessionService.Load(ctx, name)
 	if err != nil {
-		return errors.Wrap(err, "Failed to load session")
+		if !force || !canBeRemoved(ctx, err) {
+			return nil, errors.Wrap(err, "Failed to load session")
+		}
+		if err := sessionService.Remove(ctx, name); err != nil {
+			return nil, err
+		}
+		return &LogoutResult{Name: name, Removed: true}, nil
+	}
+	revoked, err := sessionService.End(ctx, session, force, revoke)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to end session")
+	}
+
+	return &LogoutResult{Name: name, Revoked: revoked, RevokeFailed: revoke && !revoked, Offline: session.Offline}, nil
+}
+
+// canBeRemoved returns true, if a session, that failed to load with the given
+// error, may be removed forcefully. This is the case for corrupted or invalid
+// sessions, but not for missing ones or if the operation was interrupted.
+func canBeRemoved(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
 	}
-	if err := sessionService.End(session, force); err != nil {
-		return errors.Wrap(err, "Failed to end session")
+	switch core.KindOf(err) {
+	case core.KindNotFound, core.KindInterrupted:
+		return false
 	}
-	fmt.Printf("Ended '%s' session and removed login credentials\n", name)
+	return true
+}
 
+// Print writes the result for humans.
+func (r *LogoutResult) Print(p *Printer) error {
+	if r.Removed {
+		p.Messagef("Removed session '%s' without ending it", r.Name)
+		return nil
+	}
+	if r.Revoked {
+		p.Messagef("Revoked the tokens of session '%s'", r.Name)
+	} else if r.RevokeFailed {
+		p.Messagef("Failed to revoke the tokens of session '%s', ending it anyway", r.Name)
+	} else if r.Offline {
+		p.Messagef("Revoked the offline token of session '%s'", r.Name)
+	}
+	p.Messagef("Ended '%s' session and removed login credentials", r.Name)
 	return nil
 }
+
+// Logout states of a session
+const (
+	LogoutEnded       = "ended"
+	LogoutFailed      = "failed"
+	LogoutInterrupted = "interrupted"
+)
+
+// LogoutAllResult is the result of the logout command with the `--all` flag.
+type LogoutAllResult struct {
+	Sessions []LogoutAllEntry
+}
+
+// LogoutAllEntry is the outcome of ending a single session.
+type LogoutAllEntry struct {
+	Name  string
+	State string
+	Err   error
+}
+
+// LogoutAll is the implementation of the logout command with the `--all` flag.
+// It ends all stored sessions in parallel. If the context is done, the sessions
+// not ended so far are reported as interrupted. The result is returned along
+// with the error, if any session wasn't ended.
+func LogoutAll(ctx context.Context, c *Container, force, revoke bool) (*LogoutAllResult, error) {
+	names, err := c.NewSessionService().List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			// each session is ended with its own service, because a session
+			// repository holds the lock of a single session only
+			if ctx.Err() != nil {
+				results[i] = ctx.Err()
+				return
+			}
+			sessionService := c.NewSessionService()
+			session, err := sessionService.Load(ctx, name)
+			if err != nil && force && canBeRemoved(ctx, err) {
+				results[i] = sessionService.Remove(ctx, name)
+				return
+			}
+			if err != nil {
+				results[i] = err
+				return
+			}
+			_, results[i] = sessionService.End(ctx, session, force, revoke)
+		}(i, name)
+	}
+	wg.Wait()
+
+	result := &LogoutAllResult{}
+	failed, interrupted := 0, 0
+	for i, name := range names {
+		entry := LogoutAllEntry{Name: name, State: LogoutEnded, Err: results[i]}
+		switch {
+		case results[i] != nil && ctx.Err() != nil:
+			interrupted++
+			entry.State = LogoutInterrupted
+		case results[i] != nil:
+			failed++
+			entry.State = LogoutFailed
+		}
+		result.Sessions = append(result.Sessions, entry)
+	}
+
+	if interrupted > 0 {
+		return result, core.NewError(core.KindInterrupted, "interrupted, %d of %d sessions were not ended", interrupted+failed, len(names))
+	}
+	if failed > 0 {
+		return result, errors.Errorf("failed to end %d of %d sessions", failed, len(names))
+	}
+	return result, nil
+}
+
+// Print writes the result for humans.
+func (r *LogoutAllResult) Print(p *Printer) error {
+	if len(r.Sessions) == 0 {
+		p.Messagef("No sessions stored")
+		return nil
+	}
+	w := p.Table()
+	for _, entry := range r.Sessions {
+		if entry.State == LogoutFailed {
+			fmt.Fprintf(w, "%s\t%s\t%v\n", entry.Name, entry.State, entry.Err)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", entry.Name, entry.State)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Result is the result of a command.
+type Result interface {
+	// Print writes the result for humans.
+	Print(p *Printer) error
+}
+
+// Printer writes the output of the commands. Data (e.g.: tokens, tables or
+// responses) is written to the output, human readable messages are written to
+// the error output, so that the data can be piped into other programs.
+type Printer struct {
+	Out io.Writer
+	Err io.Writer
+}
+
+// NewPrinter creates a printer writing to the given writers.
+func NewPrinter(out, err io.Writer) *Printer {
+	return &Printer{Out: out, Err: err}
+}
+
+// Printf writes data formatted according to a format specifier.
+func (p *Printer) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(p.Out, format, args...)
+}
+
+// Println writes data followed by a newline.
+func (p *Printer) Println(args ...interface{}) {
+	fmt.Fprintln(p.Out, args...)
+}
+
+// Messagef writes a human readable message followed by a newline.
+func (p *Printer) Messagef(format string, args ...interface{}) {
+	fmt.Fprintf(p.Err, format+"\n", args...)
+}
+
+// Table returns a writer, that aligns tab separated columns. It must be
+// flushed after writing the rows.
+func (p *Printer) Table() *tabwriter.Writer {
+	return tabwriter.NewWriter(p.Out, 0, 4, 2, ' ', 0)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Prompt asks for a value, which is read from the first line of the input of
+// the container. The prompt is written to the error output of the printer.
+func (c *Container) Prompt(label string) (string, error) {
+	fmt.Fprintf(c.Printer.Err, "%s: ", label)
+	line, err := c.input().ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "cannot read input")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// PromptSecret asks for a secret, which is read without echoing it. The
+// prompt is written to the error output of the printer.
+func (c *Container) PromptSecret(label string) (string, error) {
+	fmt.Fprintf(c.Printer.Err, "%s: ", label)
+	secret, err := c.ReadSecret()
+	fmt.Fprintln(c.Printer.Err)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read secret")
+	}
+	RegisterSecret(string(secret))
+	return string(secret), nil
+}
+
+// input returns the buffered input of the container.
+func (c *Container) input() *bufio.Reader {
+	if c.in == nil {
+		c.in = bufio.NewReader(c.In)
+	}
+	return c.in
+}
+
+// readTerminalSecret reads a secret from the controlling terminal without
+// echoing it. Stdin is used, if there is no controlling terminal.
+func readTerminalSecret() ([]byte, error) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return terminal.ReadPassword(int(os.Stdin.Fd()))
+	}
+	defer tty.Close()
+	return terminal.ReadPassword(int(tty.Fd()))
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/interface/proxy"
+	"github.com/pkg/errors"
+)
+
+// proxyShutdownTimeout is the maximum time to wait for pending requests, when
+// shutting down the proxy.
+const proxyShutdownTimeout = 5 * time.Second
+
+// Proxy is the implementation of the proxy command. It serves a reverse proxy,
+// that forwards requests to the Keycloak server of a session and injects a
+// fresh access token into each request.
+func Proxy(ctx context.Context, c *Container, name, listen string, allowedPrefixes []string, readOnly bool) error {
+	sessionService := c.NewSessionService()
+	session, err := sessionService.LoadRefresh(ctx, name, core.DefaultMinValidity)
+	if err != nil {
+		return err
+	}
+	target, err := url.Parse(session.URL)
+	if err != nil {
+		return errors.Wrapf(err, "session '%s': invalid URL", name)
+	}
+
+	// the session is kept in memory and only reloaded through the session
+	// repository, when the access token is about to expire. The repository
+	// lock serializes the refresh with other keycli processes.
+	var mu sync.Mutex
+	tokenSource := func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if session.IsExpired(core.DefaultMinValidity) {
+			refreshed, err := sessionService.LoadRefresh(ctx, name, core.DefaultMinValidity)
+			if err != nil {
+				return "", err
+			}
+			session = refreshed
+		}
+		return session.Token.AccessToken, nil
+	}
+
+	opts := proxy.Options{
+		Target:          target,
+		SkipVerify:      session.SkipVerify,
+		AllowedPrefixes: allowedPrefixes,
+		ReadOnly:        readOnly,
+		NoHTTPProxy:     session.NoProxy,
+		ListenAddr:      listen,
+	}
+	if session.Proxy != "" {
+		if opts.HTTPProxy, err = url.Parse(session.Proxy); err != nil {
+			return core.NewError(core.KindValidation, "session '%s': invalid proxy URL '%s'", name, redactURL(session.Proxy))
+		}
+	}
+	handler := proxy.NewHandler(opts, tokenSource)
+
+	// shut the server down gracefully, when the context is done
+	server := &http.Server{Addr: listen, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), proxyShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	c.Printer.Messagef("Forwarding requests from http://%s to %s using session '%s'", listen, session.URL, name)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "proxy failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// secrets holds all secret values read during the execution, so that they can
+// be removed from any output.
+var secrets = struct {
+	mu     sync.Mutex
+	values []string
+}{}
+
+// RegisterSecret registers a secret value to be redacted by `Redact`.
+func RegisterSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	secrets.values = append(secrets.values, secret)
+}
+
+// minSubstringSecretLength is the minimum length of a secret to be redacted
+// wherever it occurs. Shorter secrets are redacted only, if they appear as a
+// whole word, because they would mangle unrelated output otherwise.
+const minSubstringSecretLength = 8
+
+// Redact replaces all registered secret values in the given text.
+func Redact(text string) string {
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	for _, secret := range secrets.values {
+		if len(secret) >= minSubstringSecretLength {
+			text = strings.ReplaceAll(text, secret, "[REDACTED]")
+		} else {
+			text = replaceWord(text, secret, "[REDACTED]")
+		}
+	}
+	return text
+}
+
+// replaceWord replaces the occurrences of old in text, that are not preceded
+// or followed by a letter or digit.
+func replaceWord(text, old, new string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, old)
+		if i < 0 {
+			break
+		}
+		end := i + len(old)
+		if isWordByte(text, i-1) || isWordByte(text, end) {
+			b.WriteString(text[:i+1])
+			text = text[i+1:]
+			continue
+		}
+		b.WriteString(text[:i])
+		b.WriteString(new)
+		text = text[end:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// isWordByte returns true, if the byte at the given index is a letter or digit.
+// Indexes out of range are not.
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ReadSecretFromStdin reads a secret from the first line of the input of the
+// container.
+func (c *Container) ReadSecretFromStdin() (string, error) {
+	secret, err := firstLine(c.input())
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read secret from stdin")
+	}
+	return secret, nil
+}
+
+// ReadSecretFromFile reads a secret from the first line of a file.
+func ReadSecretFromFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Errorf("cannot read secret file: %v", err)
+	}
+	defer file.Close()
+	secret, err := firstLine(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read secret from '%s'", path)
+	}
+	return secret, nil
+}
+
+// ReadSecretFromCommand runs the given command in a shell and reads a secret
+// from the first line of its output (e.g.: `pass show kc/admin`). The command
+// reads from the input and writes its errors to the error output of the
+// container.
+func (c *Container) ReadSecretFromCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = c.In
+	cmd.Stderr = c.Printer.Err
+	output, err := cmd.Output()
+	if err != nil {
+		// the output is not included, because it might contain the secret
+		return "", errors.Errorf("secret command '%s' failed: %v", command, err)
+	}
+	secret, err := firstLine(bytes.NewReader(output))
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read secret from the output of '%s'", command)
+	}
+	return secret, nil
+}
+
+// firstLine returns the first line of a reader without the line ending.
+func firstLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", core.NewError(core.KindValidation, "secret is empty")
+	}
+	RegisterSecret(line)
+	return line, nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+// ServerResult is the result of the server command.
+type ServerResult struct {
+	URL    string
+	Server core.ServerInfo
+}
+
+// Server is the implementation of the server command. It returns the server
+// information detected on login.
+func Server(ctx context.Context, c *Container, name string) (*ServerResult, error) {
+	session, err := c.NewSessionService().Load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServerResult{URL: session.URL, Server: session.Server}, nil
+}
+
+// Print writes the server information and the capabilities of the server.
+func (r *ServerResult) Print(p *Printer) error {
+	p.Printf("URL:       %s\n", r.URL)
+	p.Printf("Base path: %s\n", formatBasePath(r.Server.BasePath))
+	p.Printf("Version:   %s\n", formatServerVersion(r.Server))
+	p.Printf("Flavour:   %s\n", r.Server.Flavour())
+	p.Println()
+
+	w := p.Table()
+	fmt.Fprintln(w, "CAPABILITY\tSINCE\tSUPPORTED\tDESCRIPTION")
+	for _, capability := range core.Capabilities {
+		status := "unknown"
+		if supported, known := r.Server.Supports(capability.ID); known && supported {
+			status = "yes"
+		} else if known {
+			status = "no"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", capability.ID, capability.MinVersion, status, capability.Description)
+	}
+	return w.Flush()
+}
+
+// formatBasePath returns a human readable base path.
+func formatBasePath(basePath string) string {
+	if basePath == "" {
+		return "/"
+	}
+	return basePath
+}
+
+// formatServerVersion returns a human readable server version.
+func formatServerVersion(server core.ServerInfo) string {
+	if server.Version == "" {
+		return "unknown (the session lacks the permission to view the server info)"
+	}
+	return server.Version
+}
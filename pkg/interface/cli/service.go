@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/infrastructure/keycloak"
+	"github.com/pkg/errors"
+)
+
+// HTTPConfig holds the settings of the HTTP requests to Keycloak.
+type HTTPConfig struct {
+	RequestTimeout time.Duration
+	MaxRetries     int
+	RateLimit      float64
+	// Debug logs retries to the error output.
+	Debug bool
+	// Trace dumps every request and response with credentials redacted.
+	Trace bool
+	// TraceFile is the file the trace is written to instead of the error
+	// output.
+	TraceFile string
+}
+
+// ConfigureHTTP sets the options of the HTTP requests to Keycloak. It must be
+// called before any service is created.
+func (c *Container) ConfigureHTTP(config HTTPConfig) error {
+	opts := keycloak.HTTPOptions{
+		RequestTimeout: config.RequestTimeout,
+		MaxRetries:     config.MaxRetries,
+		RateLimiter:    keycloak.NewRateLimiter(config.RateLimit),
+	}
+	if config.Debug {
+		opts.Logger = log.New(c.Printer.Err, "debug: ", log.LstdFlags)
+	}
+	if config.Trace || config.TraceFile != "" {
+		trace := c.Printer.Err
+		if config.TraceFile != "" {
+			// the file stays open until the program exits
+			file, err := os.OpenFile(config.TraceFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+			if err != nil {
+				return errors.Errorf("cannot open trace file: %v", err)
+			}
+			trace = file
+		}
+		opts.Trace = redactingWriter{trace}
+	}
+	c.HTTPOptions = opts
+	return nil
+}
+
+// redactingWriter redacts all registered secrets from the written data. The
+// data of each write must be complete, so that no secret is split up.
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (rw redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/aisbergg/keycli/pkg/infrastructure/jsonfile"
+	"github.com/pkg/errors"
+)
+
+// SessionStatusResult is the result of the sessions status command.
+type SessionStatusResult struct {
+	Session *core.Session
+	// Verified is true, if the signature of the access token was verified.
+	Verified bool
+	// VerifyErr is the error of the verification of the signature.
+	VerifyErr error
+}
+
+// SessionStatus is the implementation of the sessions status command.
+func SessionStatus(ctx context.Context, c *Container, name string, verify bool) (*SessionStatusResult, error) {
+	sessionService := c.NewSessionService()
+	session, err := sessionService.Load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SessionStatusResult{Session: session, Verified: verify}
+	if verify {
+		result.VerifyErr = sessionService.Verify(ctx, session)
+	}
+	return result, nil
+}
+
+// Print writes the result for humans.
+func (r *SessionStatusResult) Print(p *Printer) error {
+	session := r.Session
+	accessTokenExpiry, err := session.AccessTokenExpiry()
+	accessTokenStatus := formatExpiry(accessTokenExpiry, err)
+	refreshTokenStatus := "none"
+	if session.Token.RefreshToken != "" {
+		refreshTokenExpiry, err := session.RefreshTokenExpiry()
+		refreshTokenStatus = formatExpiry(refreshTokenExpiry, err)
+	}
+
+	signatureStatus := "not checked (use --verify)"
+	if r.Verified {
+		if r.VerifyErr != nil {
+			signatureStatus = fmt.Sprintf("invalid (%v)", r.VerifyErr)
+		} else {
+			signatureStatus = "valid"
+		}
+	}
+
+	p.Printf("Session:       %s\n", session.Name)
+	p.Printf("URL:           %s\n", session.URL)
+	p.Printf("Realm:         %s\n", session.Realm)
+	p.Printf("Client ID:     %s\n", session.ClientID)
+	p.Printf("Target realm:  %s\n", session.ResolveRealm(""))
+	p.Printf("Offline:       %t\n", session.Offline)
+	p.Printf("Proxy:         %s\n", formatProxy(session))
+	p.Printf("Server:        Keycloak %s (%s, base path %s)\n", formatServerVersion(session.Server), session.Server.Flavour(), formatBasePath(session.Server.BasePath))
+	p.Printf("Access token:  %s\n", accessTokenStatus)
+	p.Printf("Refresh token: %s\n", refreshTokenStatus)
+	p.Printf("Signature:     %s\n", signatureStatus)
+	return nil
+}
+
+// formatExpiry returns a human readable expiry status of a token.
+func formatExpiry(expiresAt time.Time, err error) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("undecodable (%v)", err)
+	case expiresAt.IsZero():
+		return "does not expire"
+	case time.Now().Before(expiresAt):
+		return fmt.Sprintf("valid until %s", expiresAt.Local().Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("expired at %s", expiresAt.Local().Format(time.RFC3339))
+	}
+}
+
+// SessionSetRealmResult is the result of the sessions set-realm command.
+type SessionSetRealmResult struct {
+	Name string
+	// Realm is the target realm. It is empty, if the login realm is managed.
+	Realm string
+}
+
+// SessionSetRealm is the implementation of the sessions set-realm command.
+func SessionSetRealm(ctx context.Context, c *Container, name, realm string) (*SessionSetRealmResult, error) {
+	if err := c.NewSessionService().SetTargetRealm(ctx, name, realm); err != nil {
+		return nil, err
+	}
+	return &SessionSetRealmResult{Name: name, Realm: realm}, nil
+}
+
+// Print writes the result for humans.
+func (r *SessionSetRealmResult) Print(p *Printer) error {
+	if r.Realm == "" {
+		p.Messagef("Session '%s' manages its login realm by default", r.Name)
+	} else {
+		p.Messagef("Session '%s' manages the realm '%s' by default", r.Name, r.Realm)
+	}
+	return nil
+}
+
+// SessionRestoreResult is the result of the sessions restore command.
+type SessionRestoreResult struct {
+	Name string
+}
+
+// SessionRestore is the implementation of the sessions restore command.
+func SessionRestore(ctx context.Context, c *Container, name string) (*SessionRestoreResult, error) {
+	if err := c.NewSessionService().Restore(ctx, name); err != nil {
+		return nil, err
+	}
+	return &SessionRestoreResult{Name: name}, nil
+}
+
+// Print writes the result for humans.
+func (r *SessionRestoreResult) Print(p *Printer) error {
+	p.Messagef("Restored session '%s' from backup", r.Name)
+	return nil
+}
+
+// Session health states
+const (
+	SessionOK      = "ok"
+	SessionExpired = "expired"
+	SessionBroken  = "broken"
+)
+
+// SessionDoctorResult is the result of the sessions doctor command.
+type SessionDoctorResult struct {
+	Sessions []SessionDoctorEntry
+}
+
+// SessionDoctorEntry is the health of a single session.
+type SessionDoctorEntry struct {
+	Name  string
+	State string
+	Err   error
+}
+
+// SessionDoctor is the implementation of the sessions doctor command. The
+// result is returned along with the error, if any session is broken or the
+// check was interrupted.
+func SessionDoctor(ctx context.Context, c *Container) (*SessionDoctorResult, error) {
+	sessionService := c.NewSessionService()
+	names, err := sessionService.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SessionDoctorResult{}
+	failed := 0
+	for i, name := range names {
+		if ctx.Err() != nil {
+			return result, core.NewError(core.KindInterrupted, "interrupted after checking %d of %d sessions", i, len(names))
+		}
+		entry := SessionDoctorEntry{Name: name, State: SessionOK}
+		session, err := sessionService.Load(ctx, name)
+		switch {
+		case err != nil:
+			failed++
+			entry.State = SessionBroken
+			entry.Err = err
+		case !session.CanBeRefreshed():
+			entry.State = SessionExpired
+		}
+		result.Sessions = append(result.Sessions, entry)
+	}
+
+	if failed > 0 {
+		return result, errors.Errorf("%d of %d sessions are broken", failed, len(names))
+	}
+	return result, nil
+}
+
+// Print writes the result for humans.
+func (r *SessionDoctorResult) Print(p *Printer) error {
+	if len(r.Sessions) == 0 {
+		p.Messagef("No sessions stored")
+		return nil
+	}
+	w := p.Table()
+	for _, entry := range r.Sessions {
+		switch entry.State {
+		case SessionBroken:
+			fmt.Fprintf(w, "%s\t%s\t%v\n", entry.Name, entry.State, entry.Err)
+		case SessionExpired:
+			fmt.Fprintf(w, "%s\t%s\tlogin again to create a new session\n", entry.Name, entry.State)
+		default:
+			fmt.Fprintf(w, "%s\t%s\n", entry.Name, entry.State)
+		}
+	}
+	return w.Flush()
+}
+
+// SessionExportResult is the result of the sessions export command.
+type SessionExportResult struct {
+	Name string
+	// Data is the encoded session.
+	Data string
+	// Path is the file, that the session was written to. It is empty, if the
+	// session is to be printed.
+	Path string
+}
+
+// SessionExport is the implementation of the sessions export command. It
+// encodes the session for the import on another machine or for the use in
+// KEYCLI_SESSION_DATA and writes it to `outPath`, unless it is empty or '-'.
+// With `accessOnly` set, the refresh token is omitted.
+func SessionExport(ctx context.Context, c *Container, name, outPath string, encrypt, accessOnly bool) (*SessionExportResult, error) {
+	session, err := c.NewSessionService().LoadRefresh(ctx, name, core.DefaultMinValidity)
+	if err != nil {
+		return nil, err
+	}
+	if accessOnly {
+		session.Token.RefreshToken = ""
+		session.Token.RefreshExpiresIn = 0
+		session.AccessOnly = true
+	}
+
+	passphrase := ""
+	if encrypt {
+		if passphrase, err = promptPassphrase(c, true); err != nil {
+			return nil, err
+		}
+	}
+	data, err := jsonfile.ExportSession(session, passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "session '%s': failed to encode", name)
+	}
+
+	result := &SessionExportResult{Name: name, Data: data}
+	if outPath == "" || outPath == "-" {
+		return result, nil
+	}
+	if err := ioutil.WriteFile(outPath, []byte(data+"\n"), 0600); err != nil {
+		return nil, errors.Errorf("cannot write exported session to '%s': %v", outPath, err)
+	}
+	result.Path = outPath
+	return result, nil
+}
+
+// Print writes the encoded session, if it wasn't written to a file.
+func (r *SessionExportResult) Print(p *Printer) error {
+	if r.Path == "" {
+		p.Println(r.Data)
+		return nil
+	}
+	p.Messagef("Exported session '%s' to %s", r.Name, r.Path)
+	return nil
+}
+
+// SessionImportResult is the result of the sessions import command.
+type SessionImportResult struct {
+	Session *core.Session
+	// Location describes where the session was stored (e.g.: the path of the
+	// session file).
+	Location string
+}
+
+// SessionImport is the implementation of the sessions import command. It
+// imports a session exported by the sessions export command.
+func SessionImport(ctx context.Context, c *Container, path, newName string, force bool) (*SessionImportResult, error) {
+	rawData, err := readInput(c.input(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := ""
+	if jsonfile.IsEncryptedExport(string(rawData)) {
+		if passphrase, err = promptPassphrase(c, false); err != nil {
+			return nil, err
+		}
+	}
+	session, err := jsonfile.ImportSession(string(rawData), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" {
+		session.Name = newName
+	}
+
+	sessionService := c.NewSessionService()
+	if err := sessionService.Import(ctx, session, force); err != nil {
+		return nil, err
+	}
+	return &SessionImportResult{Session: session, Location: sessionService.Location(session.Name)}, nil
+}
+
+// Print writes the result for humans.
+func (r *SessionImportResult) Print(p *Printer) error {
+	p.Messagef("Imported session '%s'.\nYour session was stored unencrypted in %s", r.Session.Name, r.Location)
+	return nil
+}
+
+// promptPassphrase reads a passphrase using the secret reader of the
+// container, which reads from the controlling terminal, so that the session
+// data can be read from stdin. With `confirm` set, the passphrase must be
+// entered twice.
+func promptPassphrase(c *Container, confirm bool) (string, error) {
+	passphrase, err := c.PromptSecret("Passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", core.NewError(core.KindValidation, "passphrase must not be empty")
+	}
+	if confirm {
+		confirmation, err := c.PromptSecret("Confirm passphrase")
+		if err != nil {
+			return "", err
+		}
+		if confirmation != passphrase {
+			return "", core.NewError(core.KindValidation, "passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
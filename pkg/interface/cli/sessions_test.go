@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+// memorySessionRepository holds sessions in memory.
+type memorySessionRepository struct {
+	sessions map[string]*core.Session
+	name     string
+}
+
+func (r *memorySessionRepository) Exists(name string) (bool, error) {
+	_, ok := r.sessions[name]
+	return ok, nil
+}
+
+func (r *memorySessionRepository) List() ([]string, error) {
+	names := []string{}
+	for name := range r.sessions {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (r *memorySessionRepository) Open(ctx context.Context, name string, exclusive bool) error {
+	r.name = name
+	return nil
+}
+
+func (r *memorySessionRepository) Close() error {
+	r.name = ""
+	return nil
+}
+
+func (r *memorySessionRepository) Read() (*core.Session, error) {
+	session, ok := r.sessions[r.name]
+	if !ok {
+		return nil, core.NewError(core.KindNotFound, "session '%s': does not exist", r.name)
+	}
+	sessionCopy := *session
+	return &sessionCopy, nil
+}
+
+func (r *memorySessionRepository) Write(session *core.Session) error {
+	sessionCopy := *session
+	r.sessions[r.name] = &sessionCopy
+	return nil
+}
+
+func (r *memorySessionRepository) Restore() error {
+	return nil
+}
+
+func (r *memorySessionRepository) Remove(name string) error {
+	delete(r.sessions, name)
+	return nil
+}
+
+func (r *memorySessionRepository) Location(name string) string {
+	return "memory"
+}
+
+// newToken returns an unverified JWT expiring in the given time span.
+func newToken(t *testing.T, expiresIn time.Duration) string {
+	claims := jwt.StandardClaims{ExpiresAt: jwt.At(time.Now().Add(expiresIn))}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestSessionExportAccessOnly(t *testing.T) {
+	session := &core.Session{
+		Name:     "password",
+		URL:      "https://sso.example.org/",
+		Realm:    "master",
+		ClientID: "admin-cli",
+		Created:  *jwt.Now(),
+	}
+	session.Token.AccessToken = newToken(t, time.Hour)
+	session.Token.RefreshToken = newToken(t, 10*time.Hour)
+	session.Token.ExpiresIn = 3600
+	session.Token.RefreshExpiresIn = 36000
+	session.Token.TokenType = "Bearer"
+
+	c, _, _ := newTestContainer(&fakeSessionService{session: session}, "")
+	exported, err := SessionExport(context.Background(), c, session.Name, "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &memorySessionRepository{sessions: map[string]*core.Session{}}
+	service := core.NewSessionService(repo, nil)
+	c, _, _ = newTestContainer(service, exported.Data)
+	if _, err := SessionImport(context.Background(), c, "-", "copy", false); err != nil {
+		t.Fatalf("SessionImport() = %v, want the access-only session imported", err)
+	}
+	imported := repo.sessions["copy"]
+	if imported == nil || !imported.AccessOnly || imported.Token.RefreshToken != "" {
+		t.Fatalf("imported session = %+v, want an access-only session", imported)
+	}
+
+	if _, err := service.LoadRefresh(context.Background(), "copy", 30*time.Minute); err != nil {
+		t.Errorf("LoadRefresh() = %v, want the valid access token", err)
+	}
+	_, err = service.LoadRefresh(context.Background(), "copy", 2*time.Hour)
+	if core.KindOf(err) != core.KindUnauthorized {
+		t.Errorf("LoadRefresh() = %v, want a refusal to refresh", err)
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// execCredential is the credential format consumed by Kubernetes client-go
+// credential plugins.
+type execCredential struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	Token               string `json:"token"`
+	ExpirationTimestamp string `json:"expirationTimestamp"`
+}
+
+// TokenResult is the result of the token command.
+type TokenResult struct {
+	Session *core.Session
+	// Header prints the token as an HTTP header.
+	Header bool
+	// Format is the output format: raw, env or exec-credential.
+	Format string
+	// IDTokenExpiry is the expiry of the ID token. It is only set for the
+	// exec-credential format.
+	IDTokenExpiry time.Time
+}
+
+// Token is the implementation of the token command. It returns the session
+// with an access token, which is refreshed beforehand, if it remains valid for
+// less than `minValidity`.
+func Token(ctx context.Context, c *Container, name string, minValidity time.Duration, header bool, format string) (*TokenResult, error) {
+	if header && format != "raw" {
+		return nil, core.NewError(core.KindValidation, "--header cannot be combined with other output formats")
+	}
+	switch format {
+	case "raw", "env", "exec-credential":
+	default:
+		return nil, core.NewError(core.KindValidation, "unknown output format '%s'", format)
+	}
+
+	session, err := c.NewSessionService().LoadRefresh(ctx, name, minValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TokenResult{Session: session, Header: header, Format: format}
+	if format == "exec-credential" {
+		if result.IDTokenExpiry, err = session.IDTokenExpiry(); err != nil {
+			return nil, errors.Wrapf(err, "session '%s': cannot create exec credential. Login again to obtain an ID token", session.Name)
+		}
+	}
+	return result, nil
+}
+
+// Print writes the access token in the requested format.
+func (r *TokenResult) Print(p *Printer) error {
+	session := r.Session
+	switch {
+	case r.Header:
+		p.Printf("Authorization: Bearer %s\n", session.Token.AccessToken)
+	case r.Format == "env":
+		// the output is meant for eval, sessions imported from elsewhere must
+		// not be able to inject commands
+		p.Printf("export KEYCLI_ACCESS_TOKEN=%s\n", shellQuote(session.Token.AccessToken))
+		p.Printf("export KEYCLI_URL=%s\n", shellQuote(session.URL))
+		p.Printf("export KEYCLI_REALM=%s\n", shellQuote(session.Realm))
+	case r.Format == "exec-credential":
+		return printExecCredential(p, session.Token.IDToken, r.IDTokenExpiry)
+	default:
+		p.Println(session.Token.AccessToken)
+	}
+	return nil
+}
+
+// shellQuote quotes a value for POSIX shells.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// printExecCredential prints an ID token as a Kubernetes ExecCredential.
+func printExecCredential(p *Printer, idToken string, expiresAt time.Time) error {
+	credential := execCredential{
+		APIVersion: "client.authentication.k8s.io/v1",
+		Kind:       "ExecCredential",
+		Status: execCredentialStatus{
+			Token:               idToken,
+			ExpirationTimestamp: expiresAt.UTC().Format(time.RFC3339),
+		},
+	}
+	jsonData, err := json.MarshalIndent(credential, "", "  ")
+	if err != nil {
+		return err
+	}
+	p.Println(string(jsonData))
+
+	return nil
+}
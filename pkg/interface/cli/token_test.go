@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/aisbergg/keycli/pkg/core"
+)
+
+func TestTokenResultPrintEnv(t *testing.T) {
+	session := &core.Session{URL: "https://sso.example.org/'; touch pwned; '", Realm: "it's"}
+	session.Token.AccessToken = "token"
+	out := &bytes.Buffer{}
+	result := &TokenResult{Session: session, Format: "env"}
+	if err := result.Print(NewPrinter(out, &bytes.Buffer{})); err != nil {
+		t.Fatal(err)
+	}
+
+	script := out.String() + `printf '%s\n%s\n%s' "$KEYCLI_ACCESS_TOKEN" "$KEYCLI_URL" "$KEYCLI_REALM"`
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Dir = t.TempDir()
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("cannot evaluate output %q: %v", out.String(), err)
+	}
+	want := "token\n" + session.URL + "\n" + session.Realm
+	if string(output) != want {
+		t.Errorf("evaluated values = %q, want %q", output, want)
+	}
+}
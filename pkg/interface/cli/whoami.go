@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/dgrijalva/jwt-go/v4"
+	"github.com/pkg/errors"
+)
+
+// realmManagementClientID is the ID of the client holding the roles for
+// administering a realm.
+const realmManagementClientID = "realm-management"
+
+// masterRealm is the realm, whose admins manage all other realms.
+const masterRealm = "master"
+
+// adminClientIDs returns the IDs of the clients holding the roles for
+// administering the target realm of a session. Admins of the master realm
+// hold them in the client named after the target realm (e.g.: foo-realm).
+func adminClientIDs(session *core.Session) []string {
+	if session.Realm != masterRealm {
+		return []string{realmManagementClientID}
+	}
+	return []string{realmManagementClientID, session.ResolveRealm("") + "-realm"}
+}
+
+// WhoamiResult is the result of the whoami command.
+type WhoamiResult struct {
+	Name   string
+	Claims *core.AccessTokenClaims
+	// AdminClientIDs are the IDs of the clients holding the admin roles.
+	AdminClientIDs []string
+	// Missing are the roles to check, that aren't granted.
+	Missing []string
+}
+
+// Whoami is the implementation of the whoami command. It returns information
+// about the identity and the roles of a session. The result is returned along
+// with an error, if any of the roles to check isn't granted.
+func Whoami(ctx context.Context, c *Container, name string, checkRoles []string) (*WhoamiResult, error) {
+	session, err := c.NewSessionService().LoadRefresh(ctx, name, core.DefaultMinValidity)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := session.AccessTokenClaims()
+	if err != nil {
+		return nil, errors.Wrapf(err, "session '%s'", name)
+	}
+
+	result := &WhoamiResult{Name: session.Name, Claims: claims, AdminClientIDs: adminClientIDs(session), Missing: []string{}}
+	for _, role := range checkRoles {
+		if !hasAnyRole(claims, role, result.AdminClientIDs) {
+			result.Missing = append(result.Missing, role)
+		}
+	}
+	if len(result.Missing) > 0 {
+		return result, core.NewError(core.KindForbidden, "session '%s': missing roles: %s", name, strings.Join(result.Missing, ", "))
+	}
+
+	return result, nil
+}
+
+// Print writes the result for humans.
+func (r *WhoamiResult) Print(p *Printer) error {
+	claims := r.Claims
+	p.Printf("Session:     %s\n", r.Name)
+	p.Printf("Subject:     %s\n", claims.Subject)
+	p.Printf("Username:    %s\n", claims.PreferredUsername)
+	p.Printf("Realm:       %s\n", claims.Realm())
+	p.Printf("Issuer:      %s\n", claims.Issuer)
+	p.Printf("Client:      %s\n", claims.AuthorizedParty)
+	p.Printf("Scopes:      %s\n", claims.Scope)
+	p.Printf("Issued at:   %s\n", formatClaimTime(claims.IssuedAt))
+	p.Printf("Expires at:  %s\n", formatClaimTime(claims.ExpiresAt))
+	p.Printf("Realm roles: %s\n", strings.Join(claims.RealmAccess.Roles, ", "))
+	adminRoles := []string{}
+	for _, clientID := range r.AdminClientIDs {
+		adminRoles = append(adminRoles, claims.ResourceAccess[clientID].Roles...)
+	}
+	p.Printf("Admin roles: %s\n", strings.Join(adminRoles, ", "))
+	return nil
+}
+
+// hasAnyRole returns true, if the role is granted as realm role or as client
+// role of any of the given clients.
+func hasAnyRole(claims *core.AccessTokenClaims, role string, clientIDs []string) bool {
+	for _, clientID := range clientIDs {
+		if claims.HasRole(role, clientID) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatClaimTime formats the time of a token claim.
+func formatClaimTime(t *jwt.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aisbergg/keycli/pkg/core"
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+func TestWhoamiCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		realm       string
+		targetRealm string
+		claims      core.AccessTokenClaims
+		check       []string
+		wantMissing []string
+	}{
+		{
+			name:   "realm role",
+			realm:  "foo",
+			claims: core.AccessTokenClaims{RealmAccess: core.RoleClaim{Roles: []string{"admin"}}},
+			check:  []string{"admin"},
+		},
+		{
+			name:   "realm management role",
+			realm:  "foo",
+			claims: core.AccessTokenClaims{ResourceAccess: map[string]core.RoleClaim{"realm-management": {Roles: []string{"manage-users"}}}},
+			check:  []string{"manage-users"},
+		},
+		{
+			name:        "master admin managing another realm",
+			realm:       "master",
+			targetRealm: "customer-a",
+			claims:      core.AccessTokenClaims{ResourceAccess: map[string]core.RoleClaim{"customer-a-realm": {Roles: []string{"manage-users"}}}},
+			check:       []string{"manage-users"},
+		},
+		{
+			name:        "master admin of another realm",
+			realm:       "master",
+			targetRealm: "customer-a",
+			claims:      core.AccessTokenClaims{ResourceAccess: map[string]core.RoleClaim{"customer-b-realm": {Roles: []string{"manage-users"}}}},
+			check:       []string{"manage-users"},
+			wantMissing: []string{"manage-users"},
+		},
+		{
+			name:        "client of another realm",
+			realm:       "foo",
+			targetRealm: "customer-a",
+			claims:      core.AccessTokenClaims{ResourceAccess: map[string]core.RoleClaim{"customer-a-realm": {Roles: []string{"manage-users"}}}},
+			check:       []string{"manage-users"},
+			wantMissing: []string{"manage-users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims.ExpiresAt = jwt.At(time.Now().Add(time.Hour))
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte("secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			session := &core.Session{Name: "test", Realm: tt.realm, TargetRealm: tt.targetRealm}
+			session.Token.AccessToken = token
+			c, _, _ := newTestContainer(&fakeSessionService{session: session}, "")
+
+			result, err := Whoami(context.Background(), c, "test", tt.check)
+			if len(tt.wantMissing) == 0 {
+				if err != nil {
+					t.Fatalf("Whoami() = %v, want no missing roles", err)
+				}
+				return
+			}
+			if core.KindOf(err) != core.KindForbidden || len(result.Missing) != len(tt.wantMissing) {
+				t.Fatalf("Whoami() = %v, missing %v, want missing %v", err, result.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
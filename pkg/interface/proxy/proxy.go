@@ -0,0 +1,166 @@
+// Package proxy provides a local reverse proxy, that injects access tokens
+// into the requests forwarded to Keycloak.
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// TokenSource returns a currently valid access token.
+type TokenSource func() (string, error)
+
+// Options configure the proxy.
+type Options struct {
+	// Target is the base URL of the Keycloak server.
+	Target *url.URL
+	// SkipVerify skips the TLS certificate verification of the target.
+	SkipVerify bool
+	// AllowedPrefixes restricts the forwarded requests to paths starting with
+	// one of the prefixes. All paths are allowed, if empty.
+	AllowedPrefixes []string
+	// ReadOnly rejects requests, that aren't using a safe HTTP method.
+	ReadOnly bool
+	// HTTPProxy is the URL of the HTTP proxy used for connecting to the
+	// target. The proxy is taken from the environment, if nil.
+	HTTPProxy *url.URL
+	// NoHTTPProxy disables the use of any HTTP proxy.
+	NoHTTPProxy bool
+	// ListenAddr is the address the proxy listens on. Requests are accepted
+	// only, if their Host header names this address or a loopback host, so
+	// that web pages cannot reach the proxy by DNS rebinding.
+	ListenAddr string
+}
+
+// NewHandler creates a handler, that forwards requests to the target and
+// authorizes them with a token obtained from the token source.
+func NewHandler(opts Options, tokenSource TokenSource) http.Handler {
+	reverseProxy := httputil.NewSingleHostReverseProxy(opts.Target)
+	director := reverseProxy.Director
+	reverseProxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = opts.Target.Host
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.SkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	switch {
+	case opts.NoHTTPProxy:
+		transport.Proxy = nil
+	case opts.HTTPProxy != nil:
+		transport.Proxy = http.ProxyURL(opts.HTTPProxy)
+	}
+	reverseProxy.Transport = transport
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !isAllowedHost(req.Host, opts.ListenAddr) {
+			http.Error(w, fmt.Sprintf("host '%s' is not allowed by the proxy", req.Host), http.StatusForbidden)
+			return
+		}
+		if isCrossSite(req) {
+			http.Error(w, "cross-site requests are not allowed by the proxy", http.StatusForbidden)
+			return
+		}
+		if !isAllowed(req.URL, opts.AllowedPrefixes) {
+			http.Error(w, fmt.Sprintf("path '%s' is not allowed by the proxy", req.URL.Path), http.StatusForbidden)
+			return
+		}
+		if opts.ReadOnly && !isSafeMethod(req.Method) {
+			http.Error(w, fmt.Sprintf("method %s is not allowed in read-only mode", req.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		token, err := tokenSource()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("cannot obtain access token: %v", err), http.StatusBadGateway)
+			return
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		reverseProxy.ServeHTTP(w, req)
+	})
+}
+
+// isAllowed returns true, if the path of the URL equals one of the allowed
+// prefixes or lies below one of them. Paths with dot segments or encoded
+// separators are rejected, because the server might resolve them to a path
+// outside of the allowed prefixes.
+func isAllowed(u *url.URL, prefixes []string) bool {
+	escapedPath := strings.ToLower(u.EscapedPath())
+	for _, forbidden := range []string{"..", "%2e", "%2f", "%5c", "\\"} {
+		if strings.Contains(escapedPath, forbidden) {
+			return false
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	if len(prefixes) == 0 {
+		return true
+	}
+
+	cleanPath := path.Clean("/" + u.Path)
+	for _, prefix := range prefixes {
+		cleanPrefix := path.Clean("/" + prefix)
+		if cleanPrefix == "/" || cleanPath == cleanPrefix || strings.HasPrefix(cleanPath, cleanPrefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowedHost returns true, if the host equals the listen address or names a
+// loopback host.
+func isAllowedHost(host, listenAddr string) bool {
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, listenAddr) {
+		return true
+	}
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.TrimSuffix(strings.Trim(hostname, "[]"), ".")
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isCrossSite returns true, if the request was sent by a web page of another
+// origin, as indicated by the Sec-Fetch-Site or the Origin header. Requests of
+// other clients don't send these headers.
+func isCrossSite(req *http.Request) bool {
+	switch req.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	originURL, err := url.Parse(origin)
+	return err != nil || !strings.EqualFold(originURL.Host, req.Host)
+}
+
+// isSafeMethod returns true, if the HTTP method doesn't modify resources.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
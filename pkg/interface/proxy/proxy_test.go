@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestIsAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		prefixes []string
+		want     bool
+	}{
+		{"no prefixes", "/admin/realms/master/users", nil, true},
+		{"exact prefix", "/realms/foo", []string{"/realms/foo"}, true},
+		{"below prefix", "/realms/foo/protocol/openid-connect/certs", []string{"/realms/foo"}, true},
+		{"prefix with trailing slash", "/realms/foo/account", []string{"/realms/foo/"}, true},
+		{"prefix without leading slash", "/realms/foo/account", []string{"realms/foo"}, true},
+		{"root prefix", "/admin/realms/master", []string{"/"}, true},
+		{"any of multiple prefixes", "/admin/realms/bar/users", []string{"/admin/realms/foo", "/admin/realms/bar"}, true},
+		{"query is ignored", "/admin/realms/foo/users?search=a/../b", []string{"/admin/realms/foo"}, true},
+		{"double slashes", "//admin//realms/foo/users", []string{"/admin/realms/foo"}, true},
+		{"other path", "/admin/realms/master/users", []string{"/admin/realms/foo"}, false},
+		{"segment boundary", "/admin/realms/foobar/users", []string{"/admin/realms/foo"}, false},
+		{"segment boundary exact", "/admin/realms/foobar", []string{"/admin/realms/foo"}, false},
+		{"parent of prefix", "/admin/realms", []string{"/admin/realms/foo"}, false},
+		{"dot dot traversal", "/realms/foo/../../admin/realms/master/users", []string{"/realms/foo"}, false},
+		{"dot dot without prefixes", "/realms/foo/../../admin/realms/master/users", nil, false},
+		{"dot segment", "/realms/foo/./users", []string{"/realms/foo"}, false},
+		{"encoded dot dot", "/realms/foo/%2e%2e/%2E%2E/admin/realms/master/users", []string{"/realms/foo"}, false},
+		{"encoded slash", "/realms/foo/..%2f..%2fadmin/realms/master", []string{"/realms/foo"}, false},
+		{"encoded slash in segment", "/realms/foo%2Fbar/users", []string{"/realms/foo"}, false},
+		{"encoded backslash", "/realms/foo/..%5c..%5cadmin", []string{"/realms/foo"}, false},
+		{"dot dot with path parameter", "/realms/foo/..;/..;/admin/realms/master", []string{"/realms/foo"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.ParseRequestURI(tt.uri)
+			if err != nil {
+				t.Fatalf("cannot parse '%s': %v", tt.uri, err)
+			}
+			if got := isAllowed(u, tt.prefixes); got != tt.want {
+				t.Errorf("isAllowed(%q, %q) = %t, want %t", tt.uri, tt.prefixes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsTraversal(t *testing.T) {
+	forwarded := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		forwarded = true
+	}))
+	defer target.Close()
+	targetURL, _ := url.Parse(target.URL)
+
+	handler := NewHandler(Options{Target: targetURL, AllowedPrefixes: []string{"/realms/foo"}, ListenAddr: "127.0.0.1:8080"}, func() (string, error) {
+		return "token", nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/realms/foo/../../admin/realms/master/users", nil)
+	req.Host = "127.0.0.1:8080"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if forwarded {
+		t.Error("request was forwarded to the target")
+	}
+}
+
+func TestIsAllowedHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		listenAddr string
+		want       bool
+	}{
+		{"listen address", "127.0.0.1:8080", "127.0.0.1:8080", true},
+		{"listen address of other interface", "192.168.1.5:8080", "192.168.1.5:8080", true},
+		{"localhost", "localhost:8080", "127.0.0.1:8080", true},
+		{"localhost upper case", "LOCALHOST:8080", ":8080", true},
+		{"localhost with trailing dot", "localhost.:8080", ":8080", true},
+		{"loopback address", "127.0.0.2:8080", ":8080", true},
+		{"IPv6 loopback address", "[::1]:8080", ":8080", true},
+		{"without port", "localhost", "127.0.0.1:8080", true},
+		{"rebound host name", "attacker.example.org:8080", "127.0.0.1:8080", false},
+		{"other address", "192.168.1.5:8080", "127.0.0.1:8080", false},
+		{"empty", "", "127.0.0.1:8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAllowedHost(tt.host, tt.listenAddr); got != tt.want {
+				t.Errorf("isAllowedHost(%q, %q) = %t, want %t", tt.host, tt.listenAddr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsForeignRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		want    int
+	}{
+		{"command line client", "127.0.0.1:8080", nil, http.StatusOK},
+		{"same origin", "localhost:8080", map[string]string{"Origin": "http://localhost:8080", "Sec-Fetch-Site": "same-origin"}, http.StatusOK},
+		{"typed into address bar", "localhost:8080", map[string]string{"Sec-Fetch-Site": "none"}, http.StatusOK},
+		{"DNS rebinding", "attacker.example.org:8080", map[string]string{"Origin": "http://attacker.example.org:8080"}, http.StatusForbidden},
+		{"cross-site origin", "127.0.0.1:8080", map[string]string{"Origin": "https://attacker.example.org"}, http.StatusForbidden},
+		{"opaque origin", "127.0.0.1:8080", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"other port", "127.0.0.1:8080", map[string]string{"Origin": "http://127.0.0.1:3000"}, http.StatusForbidden},
+		{"cross-site form", "127.0.0.1:8080", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"same-site form", "127.0.0.1:8080", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded := false
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				forwarded = true
+			}))
+			defer target.Close()
+			targetURL, _ := url.Parse(target.URL)
+
+			handler := NewHandler(Options{Target: targetURL, ListenAddr: "127.0.0.1:8080"}, func() (string, error) {
+				return "token", nil
+			})
+			req := httptest.NewRequest(http.MethodPost, "/admin/realms/master/users", nil)
+			req.Host = tt.host
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if forwarded != (tt.want == http.StatusOK) {
+				t.Errorf("forwarded = %t, want %t", forwarded, tt.want == http.StatusOK)
+			}
+		})
+	}
+}